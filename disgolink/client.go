@@ -2,10 +2,14 @@ package disgolink
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/disgoorg/disgolink/v3/lavalink"
 	"github.com/disgoorg/snowflake/v2"
@@ -17,76 +21,168 @@ type Client interface {
 	Node(name string) Node
 	BestNode() Node
 	RemoveNode(name string)
+	// DrainNode stops assigning new players to the node, moves all its players to the remaining nodes and removes it once all players have been moved.
+	// If a player could not be moved or the context is done first, the node stays draining and is not removed. Moves which already started are not canceled by the context.
+	DrainNode(ctx context.Context, name string) error
 
 	Player(guildID snowflake.ID) Player
 	PlayerOnNode(node Node, guildID snowflake.ID) Player
 	ExistingPlayer(guildID snowflake.ID) Player
 	RemovePlayer(guildID snowflake.ID)
 	ForPlayers(playerFunc func(player Player))
+	// PlayerCounts returns the number of players per node name. Unlike Player.Node it never selects a node for players without one.
+	PlayerCounts() map[string]int
 
 	EmitEvent(player Player, event lavalink.Message)
-	AddListeners(listeners ...EventListener)
+	// AddListeners adds listeners with priority 0 and returns a handle to remove them again.
+	AddListeners(listeners ...EventListener) ListenerHandle
+	// AddListenersWithPriority adds listeners which receive events before listeners with a lower priority.
+	// Listeners with the same priority receive events in the order they were added. See EventHandler.
+	AddListenersWithPriority(priority int, listeners ...EventListener) ListenerHandle
 	RemoveListeners(listeners ...EventListener)
 
 	AddPlugins(plugins ...Plugin)
 	ForPlugins(pluginFunc func(plugin Plugin))
 	RemovePlugins(plugins ...Plugin)
 
+	// PinGuild excludes the player of the guild from rebalancing. See WithRebalancer.
+	PinGuild(guildID snowflake.ID)
+	// UnpinGuild allows the player of the guild to be rebalanced again.
+	UnpinGuild(guildID snowflake.ID)
+	// Pinned returns true if the guild is pinned.
+	Pinned(guildID snowflake.ID) bool
+
 	UserID() snowflake.ID
+	// Tracer returns the Tracer used to start spans.
+	Tracer() Tracer
 	Close()
 
 	OnVoiceServerUpdate(ctx context.Context, guildID snowflake.ID, token string, endpoint string)
 	OnVoiceStateUpdate(ctx context.Context, guildID snowflake.ID, channelID *snowflake.ID, sessionID string)
 }
 
+var (
+	ErrNodeNotFound    = errors.New("node not found")
+	ErrNoNodeAvailable = errors.New("no node available")
+)
+
 func New(userID snowflake.ID, opts ...ConfigOpt) Client {
 	cfg := DefaultConfig()
 	cfg.Apply(opts)
 	cfg.Logger = cfg.Logger.With(slog.String("name", "disgolink_client"))
+	if cfg.Tracer == nil {
+		cfg.Tracer = noopTracer{}
+	}
+	if cfg.HeartbeatInterval > 0 && cfg.HeartbeatTimeout <= 0 {
+		// pings without a timeout fail immediately and would reconnect the node on every interval
+		cfg.HeartbeatTimeout = 10 * time.Second
+	}
 
-	return &clientImpl{
-		logger:     cfg.Logger,
-		httpClient: cfg.HTTPClient,
-		userID:     userID,
-		nodes:      map[string]Node{},
-		players:    map[snowflake.ID]Player{},
-		listeners:  cfg.Listeners,
-		plugins:    cfg.Plugins,
+	c := &clientImpl{
+		logger:              cfg.Logger,
+		httpClient:          cfg.HTTPClient,
+		failoverGracePeriod: cfg.FailoverGracePeriod,
+		loadBalancer:        cfg.LoadBalancer,
+		regionResolver:      cfg.RegionResolver,
+		sessionStore:        cfg.SessionStore,
+		resumeTimeout:       cfg.ResumeTimeout,
+		heartbeatInterval:   cfg.HeartbeatInterval,
+		heartbeatTimeout:    cfg.HeartbeatTimeout,
+		statsTimeout:        cfg.StatsTimeout,
+		statsHistorySize:    cfg.StatsHistorySize,
+		statsStaleAfter:     cfg.StatsStaleAfter,
+		healthThresholds:    cfg.HealthThresholds,
+		restRetry:           cfg.RestRetry,
+		restMiddlewares:     cfg.RestMiddlewares,
+		tracer:              cfg.Tracer,
+		slowListenerAfter:   cfg.SlowListenerAfter,
+		userID:              userID,
+		nodes:               map[string]Node{},
+		drainingNodes:       map[string]struct{}{},
+		players:             map[snowflake.ID]Player{},
+		pinned:              map[snowflake.ID]struct{}{},
+		plugins:             cfg.Plugins,
+	}
+	c.AddListeners(cfg.Listeners...)
+	if cfg.Dispatcher != nil {
+		c.dispatcher = newDispatcher(c, *cfg.Dispatcher)
+	}
+	if cfg.Rebalancer != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c.cancelRebalancer = cancel
+		go newRebalancer(c, *cfg.Rebalancer).run(ctx)
 	}
+	return c
 }
 
 var _ Client = (*clientImpl)(nil)
 
 type clientImpl struct {
-	logger     *slog.Logger
-	httpClient *http.Client
-	userID     snowflake.ID
-
-	nodesMu sync.Mutex
-	nodes   map[string]Node
+	logger              *slog.Logger
+	httpClient          *http.Client
+	failoverGracePeriod time.Duration
+	loadBalancer        LoadBalancer
+	regionResolver      RegionResolver
+	sessionStore        SessionStore
+	resumeTimeout       time.Duration
+	heartbeatInterval   time.Duration
+	heartbeatTimeout    time.Duration
+	statsTimeout        time.Duration
+	statsHistorySize    int
+	statsStaleAfter     time.Duration
+	healthThresholds    HealthThresholds
+	restRetry           RestRetryConfig
+	restMiddlewares     []RestMiddleware
+	tracer              Tracer
+	slowListenerAfter   time.Duration
+	userID              snowflake.ID
+
+	nodesMu       sync.Mutex
+	nodes         map[string]Node
+	drainingNodes map[string]struct{}
 
 	playersMu sync.Mutex
 	players   map[snowflake.ID]Player
 
+	pinnedMu         sync.Mutex
+	pinned           map[snowflake.ID]struct{}
+	cancelRebalancer context.CancelFunc
+
+	// listeners is sorted by priority and replaced instead of modified, so it can be used after unlocking listenersMu.
 	listenersMu sync.Mutex
-	listeners   []EventListener
+	listeners   []*listenerEntry
+	dispatcher  *dispatcher
 
 	pluginsMu sync.Mutex
 	plugins   []Plugin
 }
 
 func (c *clientImpl) AddNode(ctx context.Context, config NodeConfig) (Node, error) {
-	node := &nodeImpl{
-		logger:   c.logger.With(slog.String("name", "disgolink_node"), slog.String("node_name", config.Name)),
-		config:   config,
-		lavalink: c,
-		status:   StatusDisconnected,
+	if c.sessionStore != nil && config.SessionID == "" {
+		sessionID, err := c.sessionStore.Load(config.Name)
+		if err != nil {
+			c.logger.ErrorContext(ctx, "failed to load session id", slog.Any("err", err), slog.String("node_name", config.Name))
+		}
+		config.SessionID = sessionID
 	}
-	node.rest = &restClientImpl{
-		logger:     c.logger.With(slog.String("name", "disgolink_rest_client"), slog.String("node_name", config.Name)),
-		node:       node,
-		httpClient: c.httpClient,
+
+	node := &nodeImpl{
+		logger:              c.logger.With(slog.String("name", "disgolink_node"), slog.String("node_name", config.Name)),
+		config:              config,
+		lavalink:            c,
+		failoverGracePeriod: c.failoverGracePeriod,
+		resuming:            c.sessionStore != nil,
+		resumeTimeout:       c.resumeTimeout,
+		heartbeatInterval:   c.heartbeatInterval,
+		heartbeatTimeout:    c.heartbeatTimeout,
+		statsTimeout:        c.statsTimeout,
+		statsHistory:        newStatsHistory(c.statsHistorySize),
+		statsStaleAfter:     c.statsStaleAfter,
+		healthThresholds:    c.healthThresholds,
+		status:              StatusDisconnected,
 	}
+	middlewares := append(append([]RestMiddleware{}, c.restMiddlewares...), config.RestMiddlewares...)
+	node.rest = newRestClient(c.logger.With(slog.String("name", "disgolink_rest_client"), slog.String("node_name", config.Name)), node, c.httpClient, c.restRetry, middlewares)
 	if err := node.Open(ctx); err != nil {
 		return nil, err
 	}
@@ -112,28 +208,141 @@ func (c *clientImpl) Node(name string) Node {
 }
 
 func (c *clientImpl) BestNode() Node {
+	return c.selectNode(0, "")
+}
+
+// selectNode lets the LoadBalancer choose between all connected nodes which accept new players.
+// If a region is given and any of these nodes is in the region only those are considered.
+func (c *clientImpl) selectNode(guildID snowflake.ID, region string) Node {
 	c.nodesMu.Lock()
-	defer c.nodesMu.Unlock()
-	var bestNode Node
-	for _, node := range c.nodes {
-		if bestNode == nil || node.Stats().Better(bestNode.Stats()) {
-			bestNode = node
+	var (
+		nodes        []Node
+		regionNodes  []Node
+		fallbackNode Node
+	)
+	for name, node := range c.nodes {
+		if _, ok := c.drainingNodes[name]; ok {
+			continue
+		}
+		if fallbackNode == nil {
+			fallbackNode = node
+		}
+		if node.Status() != StatusConnected {
+			continue
+		}
+		nodes = append(nodes, node)
+		if region != "" && node.Config().HasRegion(region) {
+			regionNodes = append(regionNodes, node)
 		}
 	}
-	return bestNode
+	c.nodesMu.Unlock()
+
+	if len(regionNodes) > 0 {
+		nodes = regionNodes
+	}
+
+	if len(nodes) == 0 {
+		// no node is connected, fall back to any node so players can still be created
+		return fallbackNode
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Config().Name < nodes[j].Config().Name
+	})
+	return c.loadBalancer.SelectNode(nodes, guildID)
 }
 
 func (c *clientImpl) RemoveNode(name string) {
 	c.nodesMu.Lock()
-	defer c.nodesMu.Unlock()
-	if node, ok := c.nodes[name]; ok {
-		node.Close()
+	node, ok := c.nodes[name]
+	if ok {
 		delete(c.nodes, name)
+		delete(c.drainingNodes, name)
+	}
+	c.nodesMu.Unlock()
+	if !ok {
+		return
+	}
+
+	// close the node without holding nodesMu, so listeners of the emitted events can still access the client
+	node.Close()
+	c.EmitEvent(nil, NodeRemovedEvent{
+		Node_: node,
+	})
+}
+
+func (c *clientImpl) DrainNode(ctx context.Context, name string) error {
+	c.nodesMu.Lock()
+	node, ok := c.nodes[name]
+	if !ok {
+		c.nodesMu.Unlock()
+		return ErrNodeNotFound
+	}
+	c.drainingNodes[name] = struct{}{}
+	c.nodesMu.Unlock()
+
+	var players []Player
+	c.ForPlayers(func(player Player) {
+		if player.Node() == node {
+			players = append(players, player)
+		}
+	})
+
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+	)
+	addErr := func(err error) {
+		errsMu.Lock()
+		defer errsMu.Unlock()
+		errs = append(errs, err)
 	}
+
+	// a move which was started should not be aborted halfway by the drain timeout
+	moveCtx := context.WithoutCancel(ctx)
+	for _, player := range players {
+		target := c.selectNode(player.GuildID(), "")
+		if target == nil || target == node {
+			addErr(ErrNoNodeAvailable)
+			break
+		}
+
+		wg.Add(1)
+		go func(player Player) {
+			defer wg.Done()
+			if err := player.MoveTo(moveCtx, target); err != nil {
+				addErr(fmt.Errorf("failed to move player %s: %w", player.GuildID(), err))
+			}
+		}(player)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		addErr(ctx.Err())
+	}
+
+	errsMu.Lock()
+	err := errors.Join(errs...)
+	errsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.RemoveNode(name)
+	return nil
 }
 
 func (c *clientImpl) Player(guildID snowflake.ID) Player {
-	return c.PlayerOnNode(c.BestNode(), guildID)
+	if player := c.ExistingPlayer(guildID); player != nil {
+		return player
+	}
+	return c.PlayerOnNode(c.selectNode(guildID, ""), guildID)
 }
 
 func (c *clientImpl) PlayerOnNode(node Node, guildID snowflake.ID) Player {
@@ -166,44 +375,84 @@ func (c *clientImpl) RemovePlayer(guildID snowflake.ID) {
 }
 
 func (c *clientImpl) ForPlayers(playerFunc func(player Player)) {
+	// call playerFunc without holding playersMu, so it can select a node or create players
+	for _, player := range c.playerSnapshot() {
+		playerFunc(player)
+	}
+}
+
+func (c *clientImpl) playerSnapshot() []Player {
 	c.playersMu.Lock()
 	defer c.playersMu.Unlock()
+	players := make([]Player, 0, len(c.players))
 	for _, player := range c.players {
-		playerFunc(player)
+		players = append(players, player)
 	}
+	return players
+}
+
+func (c *clientImpl) PlayerCounts() map[string]int {
+	counts := map[string]int{}
+	for _, player := range c.playerSnapshot() {
+		if node := playerNode(player); node != nil {
+			counts[node.Config().Name]++
+		}
+	}
+	return counts
 }
 
 func (c *clientImpl) EmitEvent(player Player, event lavalink.Message) {
 	c.listenersMu.Lock()
-	defer c.listenersMu.Unlock()
+	listeners := c.listeners
+	c.listenersMu.Unlock()
+
+	if c.dispatcher != nil {
+		c.dispatcher.dispatch(dispatchedEvent{
+			listeners: listeners,
+			player:    player,
+			event:     event,
+		})
+		return
+	}
+	c.callListeners(listeners, player, event)
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			c.logger.Error("recovered from panic in event listener", slog.Any("r", r), slog.String("stack", string(debug.Stack())))
-			return
+func (c *clientImpl) AddListeners(listeners ...EventListener) ListenerHandle {
+	return c.AddListenersWithPriority(0, listeners...)
+}
+
+func (c *clientImpl) AddListenersWithPriority(priority int, listeners ...EventListener) ListenerHandle {
+	entries := make([]*listenerEntry, len(listeners))
+	for i, listener := range listeners {
+		entries[i] = &listenerEntry{
+			listener: listener,
+			priority: priority,
 		}
-	}()
-	for _, listener := range c.listeners {
-		listener.OnEvent(player, event)
 	}
-}
 
-func (c *clientImpl) AddListeners(listeners ...EventListener) {
 	c.listenersMu.Lock()
 	defer c.listenersMu.Unlock()
-	c.listeners = append(c.listeners, listeners...)
+	// insert after all listeners with the same or a higher priority
+	i := sort.Search(len(c.listeners), func(i int) bool {
+		return c.listeners[i].priority < priority
+	})
+	c.listeners = slices.Insert(slices.Clone(c.listeners), i, entries...)
+	return &listenerHandle{
+		client:  c,
+		entries: entries,
+	}
 }
 
 func (c *clientImpl) RemoveListeners(listeners ...EventListener) {
+	c.removeListenerEntries(func(entry *listenerEntry) bool {
+		return slices.Contains(listeners, entry.listener)
+	})
+}
+
+func (c *clientImpl) removeListenerEntries(remove func(entry *listenerEntry) bool) {
 	c.listenersMu.Lock()
 	defer c.listenersMu.Unlock()
-	for _, listener := range listeners {
-		for i, ln := range c.listeners {
-			if ln == listener {
-				c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
-			}
-		}
-	}
+	c.listeners = slices.DeleteFunc(slices.Clone(c.listeners), remove)
 }
 
 func (c *clientImpl) AddPlugins(plugins ...Plugin) {
@@ -236,18 +485,91 @@ func (c *clientImpl) UserID() snowflake.ID {
 	return c.userID
 }
 
+func (c *clientImpl) PinGuild(guildID snowflake.ID) {
+	c.pinnedMu.Lock()
+	defer c.pinnedMu.Unlock()
+	c.pinned[guildID] = struct{}{}
+}
+
+func (c *clientImpl) UnpinGuild(guildID snowflake.ID) {
+	c.pinnedMu.Lock()
+	defer c.pinnedMu.Unlock()
+	delete(c.pinned, guildID)
+}
+
+func (c *clientImpl) Pinned(guildID snowflake.ID) bool {
+	c.pinnedMu.Lock()
+	defer c.pinnedMu.Unlock()
+	_, ok := c.pinned[guildID]
+	return ok
+}
+
+func (c *clientImpl) Tracer() Tracer {
+	return c.tracer
+}
+
 func (c *clientImpl) Close() {
+	if c.cancelRebalancer != nil {
+		c.cancelRebalancer()
+	}
+
+	// close the nodes without holding nodesMu, so listeners of the emitted events can still access the client
 	c.nodesMu.Lock()
-	defer c.nodesMu.Unlock()
+	nodes := make([]Node, 0, len(c.nodes))
 	for _, node := range c.nodes {
+		nodes = append(nodes, node)
+	}
+	c.nodesMu.Unlock()
+
+	for _, node := range nodes {
+		if c.sessionStore != nil && node.SessionID() != "" {
+			if err := c.sessionStore.Save(node.Config().Name, node.SessionID()); err != nil {
+				c.logger.Error("failed to save session id", slog.Any("err", err), slog.String("node_name", node.Config().Name))
+			}
+		}
 		node.Close()
 	}
+
+	if c.dispatcher != nil {
+		c.dispatcher.close()
+	}
 }
 
 func (c *clientImpl) OnVoiceServerUpdate(ctx context.Context, guildID snowflake.ID, token string, endpoint string) {
-	c.Player(guildID).OnVoiceServerUpdate(ctx, token, endpoint)
+	if c.regionResolver == nil {
+		c.Player(guildID).OnVoiceServerUpdate(ctx, token, endpoint)
+		return
+	}
+
+	region := c.regionResolver.Region(endpoint)
+	player := c.ExistingPlayer(guildID)
+	if player == nil {
+		player = c.PlayerOnNode(c.selectNode(guildID, region), guildID)
+	} else if p, ok := player.(*playerImpl); ok && p.currentNode() == nil {
+		// players created by the voice state update wait for the region, so they do not have to be moved
+		p.bindNode(c.selectNode(guildID, region))
+	} else if region != "" && (player.Node() == nil || !player.Node().Config().HasRegion(region)) {
+		if node := c.selectNode(guildID, region); node != nil && node.Config().HasRegion(region) {
+			if err := player.MoveTo(ctx, node); err != nil {
+				c.logger.ErrorContext(ctx, "failed to move player to voice server region", slog.Any("err", err), slog.Int64("guild_id", int64(guildID)), slog.String("region", region))
+			}
+		}
+	}
+	player.OnVoiceServerUpdate(ctx, token, endpoint)
 }
 
 func (c *clientImpl) OnVoiceStateUpdate(ctx context.Context, guildID snowflake.ID, channelID *snowflake.ID, sessionID string) {
-	c.Player(guildID).OnVoiceStateUpdate(ctx, channelID, sessionID)
+	player := c.ExistingPlayer(guildID)
+	if player == nil {
+		if channelID == nil {
+			return
+		}
+		// Discord sends the voice server update with the region after the voice state update, so the node is selected then
+		var node Node
+		if c.regionResolver == nil {
+			node = c.selectNode(guildID, "")
+		}
+		player = c.PlayerOnNode(node, guildID)
+	}
+	player.OnVoiceStateUpdate(ctx, channelID, sessionID)
 }
@@ -10,16 +10,40 @@ import (
 
 func DefaultConfig() *Config {
 	return &Config{
-		Logger:     slog.Default(),
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Logger:            slog.Default(),
+		HTTPClient:        &http.Client{Timeout: 10 * time.Second},
+		LoadBalancer:      NewPenaltyLoadBalancer(),
+		HeartbeatInterval: 30 * time.Second,
+		HeartbeatTimeout:  10 * time.Second,
+		StatsHistorySize:  10,
+		SlowListenerAfter: time.Second,
+		StatsStaleAfter:   2 * time.Minute,
+		Tracer:            noopTracer{},
 	}
 }
 
 type Config struct {
-	Logger     *slog.Logger
-	HTTPClient *http.Client
-	Listeners  []EventListener
-	Plugins    []Plugin
+	Logger              *slog.Logger
+	HTTPClient          *http.Client
+	Listeners           []EventListener
+	Plugins             []Plugin
+	FailoverGracePeriod time.Duration
+	LoadBalancer        LoadBalancer
+	RegionResolver      RegionResolver
+	SessionStore        SessionStore
+	ResumeTimeout       time.Duration
+	HeartbeatInterval   time.Duration
+	HeartbeatTimeout    time.Duration
+	StatsTimeout        time.Duration
+	StatsHistorySize    int
+	StatsStaleAfter     time.Duration
+	HealthThresholds    HealthThresholds
+	RestRetry           RestRetryConfig
+	RestMiddlewares     []RestMiddleware
+	Tracer              Tracer
+	Rebalancer          *RebalanceConfig
+	Dispatcher          *DispatcherConfig
+	SlowListenerAfter   time.Duration
 }
 
 type ConfigOpt func(config *Config)
@@ -58,3 +82,116 @@ func WithPlugins(plugins ...Plugin) ConfigOpt {
 		config.Plugins = append(config.Plugins, plugins...)
 	}
 }
+
+// WithFailover enables moving players of a node which has been disconnected for longer than the given grace period to the best available node.
+// A grace period of 0 disables failover.
+func WithFailover(gracePeriod time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.FailoverGracePeriod = gracePeriod
+	}
+}
+
+// WithLoadBalancer lets you choose how the Node for new players is selected. Defaults to NewPenaltyLoadBalancer.
+func WithLoadBalancer(loadBalancer LoadBalancer) ConfigOpt {
+	return func(config *Config) {
+		config.LoadBalancer = loadBalancer
+	}
+}
+
+// WithRegionResolver enables region aware node selection. New players are created on a node tagged with the region of their voice server
+// and players are moved when their voice server changes to another region. See NodeConfig.Regions and NewRegionResolver.
+func WithRegionResolver(regionResolver RegionResolver) ConfigOpt {
+	return func(config *Config) {
+		config.RegionResolver = regionResolver
+	}
+}
+
+// WithSessionStore enables session resuming with the given timeout. The session ids of all nodes are saved to the SessionStore on Client.Close
+// and loaded when adding a node without a NodeConfig.SessionID, so playback continues after a restart.
+// A resumeTimeout of 0 defaults to 60 seconds, as Lavalink would otherwise drop the session as soon as the client disconnects.
+func WithSessionStore(sessionStore SessionStore, resumeTimeout time.Duration) ConfigOpt {
+	if resumeTimeout <= 0 {
+		resumeTimeout = 60 * time.Second
+	}
+	return func(config *Config) {
+		config.SessionStore = sessionStore
+		config.ResumeTimeout = resumeTimeout
+	}
+}
+
+// WithHeartbeat lets you configure how often nodes are pinged and how long to wait for a pong or any other message before the connection is considered dead and reconnected.
+// An interval of 0 disables heartbeating. Defaults to an interval of 30 seconds and a timeout of 10 seconds, which is also used if the timeout is 0.
+func WithHeartbeat(interval time.Duration, timeout time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.HeartbeatInterval = interval
+		config.HeartbeatTimeout = timeout
+	}
+}
+
+// WithStatsTimeout reconnects nodes which have not sent a lavalink.StatsMessage for the given duration. Lavalink sends stats every minute.
+// A timeout of 0 disables this check, which is the default.
+func WithStatsTimeout(timeout time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.StatsTimeout = timeout
+	}
+}
+
+// WithStatsHistory lets you configure how many lavalink.Stats are kept per node and after which duration without a lavalink.StatsMessage
+// the history is backfilled from RestClient.Stats. A staleAfter of 0 disables backfilling. Defaults to 10 stats and 2 minutes.
+func WithStatsHistory(size int, staleAfter time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.StatsHistorySize = size
+		config.StatsStaleAfter = staleAfter
+	}
+}
+
+// WithHealthThresholds emits NodeDegradedEvent & NodeRecoveredEvent when the StatsSummary of a node crosses the given thresholds.
+func WithHealthThresholds(thresholds HealthThresholds) ConfigOpt {
+	return func(config *Config) {
+		config.HealthThresholds = thresholds
+	}
+}
+
+// WithRebalancer periodically moves players from overloaded nodes to healthy ones based on their StatsSummary.
+// Players of guilds pinned with Client.PinGuild are never moved by the rebalancer.
+func WithRebalancer(rebalanceConfig RebalanceConfig) ConfigOpt {
+	return func(config *Config) {
+		config.Rebalancer = &rebalanceConfig
+	}
+}
+
+// WithDispatcher calls event listeners asynchronously on a pool of workers instead of the goroutine emitting the event.
+// Events of the same guild or node are still received in the order they were emitted.
+func WithDispatcher(dispatcherConfig DispatcherConfig) ConfigOpt {
+	return func(config *Config) {
+		config.Dispatcher = &dispatcherConfig
+	}
+}
+
+// WithSlowListenerWarning logs a warning when an event listener takes longer than the given duration to handle an event. 0 disables the warning. Defaults to 1 second.
+func WithSlowListenerWarning(after time.Duration) ConfigOpt {
+	return func(config *Config) {
+		config.SlowListenerAfter = after
+	}
+}
+
+// WithRestRetry lets you configure how failed rest requests are retried. Retrying is disabled by default.
+func WithRestRetry(restRetry RestRetryConfig) ConfigOpt {
+	return func(config *Config) {
+		config.RestRetry = restRetry
+	}
+}
+
+// WithRestMiddlewares adds RestMiddleware(s) to the RestClient of all nodes. They are called in the given order before the ones of NodeConfig.RestMiddlewares.
+func WithRestMiddlewares(middlewares ...RestMiddleware) ConfigOpt {
+	return func(config *Config) {
+		config.RestMiddlewares = append(config.RestMiddlewares, middlewares...)
+	}
+}
+
+// WithTracer lets you trace rest requests, player updates, track loading, node reconnects and event dispatching.
+func WithTracer(tracer Tracer) ConfigOpt {
+	return func(config *Config) {
+		config.Tracer = tracer
+	}
+}
@@ -0,0 +1,45 @@
+package disgolink
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_DrainNode(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	client := newTestClient()
+	source := newTestMoveNode(t, "source", http.StatusOK, &mu, &requests)
+	target := newTestMoveNode(t, "target", http.StatusOK, &mu, &requests)
+	client.nodes[source.config.Name] = source
+	client.nodes[target.config.Name] = target
+	player := client.PlayerOnNode(source, 1)
+
+	assert.NoError(t, client.DrainNode(context.Background(), "source"))
+	assert.Equal(t, Node(target), player.Node())
+	assert.Nil(t, client.Node("source"))
+}
+
+func TestClient_DrainNodeMoveFailed(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	client := newTestClient()
+	source := newTestMoveNode(t, "source", http.StatusOK, &mu, &requests)
+	target := newTestMoveNode(t, "target", http.StatusInternalServerError, &mu, &requests)
+	client.nodes[source.config.Name] = source
+	client.nodes[target.config.Name] = target
+	player := client.PlayerOnNode(source, 1)
+
+	assert.ErrorIs(t, client.DrainNode(context.Background(), "source"), ErrServerError)
+	assert.Equal(t, Node(source), player.Node())
+	assert.Equal(t, Node(source), client.Node("source"), "the node should not be removed while it still has players")
+	assert.Equal(t, Node(target), client.BestNode(), "the node should still be draining")
+}
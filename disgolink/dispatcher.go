@@ -0,0 +1,199 @@
+package disgolink
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// OverflowPolicy decides what happens when an event is emitted while the queue of its worker is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the queue has space again, which blocks the emitting goroutine.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the emitted event.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued event of the worker to make space for the emitted event.
+	OverflowDropOldest
+)
+
+// DispatcherConfig configures the asynchronous event dispatcher. See WithDispatcher.
+type DispatcherConfig struct {
+	// Workers is the number of goroutines calling the listeners. Defaults to 4.
+	Workers int
+	// QueueSize is the number of events each worker can queue. Defaults to 100.
+	QueueSize int
+	// Overflow decides what happens when a queue is full. Defaults to OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+func (c DispatcherConfig) withDefaults() DispatcherConfig {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 100
+	}
+	return c
+}
+
+type dispatchedEvent struct {
+	listeners []*listenerEntry
+	player    Player
+	event     lavalink.Message
+}
+
+func newDispatcher(client *clientImpl, config DispatcherConfig) *dispatcher {
+	config = config.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &dispatcher{
+		client: client,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+		queues: make([]chan dispatchedEvent, config.Workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan dispatchedEvent, config.QueueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+	return d
+}
+
+// dispatcher calls listeners on a pool of workers. Events are sharded by guild, so events of the same guild are received in order.
+type dispatcher struct {
+	client *clientImpl
+	config DispatcherConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+	queues []chan dispatchedEvent
+	wg     sync.WaitGroup
+}
+
+func (d *dispatcher) dispatch(e dispatchedEvent) {
+	if d.ctx.Err() != nil {
+		// the client is closed, deliver the remaining events directly
+		d.client.callListeners(e.listeners, e.player, e.event)
+		return
+	}
+
+	queue := d.queues[d.shard(e)%uint64(len(d.queues))]
+	select {
+	case queue <- e:
+		return
+	default:
+	}
+
+	switch d.config.Overflow {
+	case OverflowDropNewest:
+		d.client.logger.Warn("event queue is full, dropping event", slog.String("event", fmt.Sprintf("%T", e.event)))
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case queue <- e:
+				return
+			default:
+			}
+			select {
+			case dropped := <-queue:
+				d.client.logger.Warn("event queue is full, dropping oldest event", slog.String("event", fmt.Sprintf("%T", dropped.event)))
+			default:
+			}
+		}
+
+	default:
+		select {
+		case queue <- e:
+		case <-d.ctx.Done():
+			d.client.callListeners(e.listeners, e.player, e.event)
+		}
+	}
+}
+
+// shard returns the guild id of the event or a hash of the node name for NodeEvent(s), so related events are handled by the same worker.
+func (d *dispatcher) shard(e dispatchedEvent) uint64 {
+	if e.player != nil {
+		return uint64(e.player.GuildID())
+	}
+	switch event := e.event.(type) {
+	case interface{ GuildID() snowflake.ID }:
+		return uint64(event.GuildID())
+	case NodeEvent:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(event.Node().Config().Name))
+		return h.Sum64()
+	}
+	return 0
+}
+
+func (d *dispatcher) work(queue chan dispatchedEvent) {
+	defer d.wg.Done()
+	for {
+		select {
+		case e := <-queue:
+			d.client.callListeners(e.listeners, e.player, e.event)
+		case <-d.ctx.Done():
+			// deliver already queued events before exiting
+			for {
+				select {
+				case e := <-queue:
+					d.client.callListeners(e.listeners, e.player, e.event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// close stops the workers after they delivered all queued events.
+func (d *dispatcher) close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// callListeners calls each listener until an EventHandler handled the event, recovers from panics and warns about listeners which take longer than Config.SlowListenerAfter.
+func (c *clientImpl) callListeners(listeners []*listenerEntry, player Player, event lavalink.Message) {
+	var (
+		handled         bool
+		handledPriority int
+	)
+	for _, entry := range listeners {
+		if handled && entry.priority < handledPriority {
+			return
+		}
+		if c.callListener(entry.listener, player, event) && !handled {
+			handled = true
+			handledPriority = entry.priority
+		}
+	}
+}
+
+func (c *clientImpl) callListener(listener EventListener, player Player, event lavalink.Message) (handled bool) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.Error("recovered from panic in event listener", slog.Any("r", r), slog.String("stack", string(debug.Stack())))
+		}
+		if c.slowListenerAfter > 0 {
+			if elapsed := time.Since(start); elapsed > c.slowListenerAfter {
+				c.logger.Warn("slow event listener", slog.String("listener", fmt.Sprintf("%T", listener)), slog.String("event", fmt.Sprintf("%T", event)), slog.Duration("elapsed", elapsed))
+			}
+		}
+	}()
+	if handler, ok := listener.(EventHandler); ok {
+		return handler.HandleEvent(player, event)
+	}
+	listener.OnEvent(player, event)
+	return false
+}
@@ -0,0 +1,81 @@
+package disgolink
+
+import (
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(opts ...ConfigOpt) *clientImpl {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(snowflake.ID(1), append([]ConfigOpt{WithLogger(logger)}, opts...)...).(*clientImpl)
+}
+
+func TestDispatcher_Order(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events = map[snowflake.ID][]int{}
+	)
+	client := newTestClient(WithDispatcher(DispatcherConfig{Workers: 3, QueueSize: 1}), WithListenerFunc(func(p Player, e lavalink.TrackStuckEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events[e.GuildID()] = append(events[e.GuildID()], int(e.Threshold))
+	}))
+
+	for i := 0; i < 100; i++ {
+		client.EmitEvent(nil, lavalink.TrackStuckEvent{GuildID_: snowflake.ID(i % 5), Threshold: lavalink.Duration(i)})
+	}
+	client.Close()
+
+	for guildID, thresholds := range events {
+		assert.Len(t, thresholds, 20)
+		assert.IsIncreasing(t, thresholds, "guild %d", guildID)
+	}
+}
+
+func TestDispatcher_DropNewest(t *testing.T) {
+	block := make(chan struct{})
+	var received []lavalink.Duration
+	client := newTestClient(WithDispatcher(DispatcherConfig{Workers: 1, QueueSize: 1, Overflow: OverflowDropNewest}), WithListenerFunc(func(p Player, e lavalink.TrackStuckEvent) {
+		<-block
+		received = append(received, e.Threshold)
+	}))
+
+	client.EmitEvent(nil, lavalink.TrackStuckEvent{Threshold: 1})
+	// wait until the worker took the first event
+	for len(client.dispatcher.queues[0]) > 0 {
+		runtime.Gosched()
+	}
+	client.EmitEvent(nil, lavalink.TrackStuckEvent{Threshold: 2})
+	client.EmitEvent(nil, lavalink.TrackStuckEvent{Threshold: 3})
+	close(block)
+	client.Close()
+
+	assert.Equal(t, []lavalink.Duration{1, 2}, received)
+}
+
+func TestEmitEvent_PanicAndReentrancy(t *testing.T) {
+	client := newTestClient()
+
+	var called int
+	client.AddListeners(
+		NewListenerFunc(func(p Player, e lavalink.TrackStartEvent) {
+			panic("test")
+		}),
+		NewListenerFunc(func(p Player, e lavalink.TrackStartEvent) {
+			called++
+			// adding listeners from a listener must not deadlock
+			client.AddListeners(NewListenerFunc(func(p Player, e lavalink.TrackStartEvent) {}))
+		}),
+	)
+
+	client.EmitEvent(nil, lavalink.TrackStartEvent{})
+	assert.Equal(t, 1, called)
+	assert.Len(t, client.listeners, 3)
+}
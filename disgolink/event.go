@@ -6,6 +6,18 @@ type EventListener interface {
 	OnEvent(player Player, event lavalink.Message)
 }
 
+// EventHandler is an EventListener which can stop listeners with a lower priority from receiving an event.
+// HandleEvent is called instead of OnEvent. Returning true marks the event as handled.
+type EventHandler interface {
+	EventListener
+	HandleEvent(player Player, event lavalink.Message) bool
+}
+
+// ListenerHandle removes the listeners it was returned for from the Client.
+type ListenerHandle interface {
+	Remove()
+}
+
 func NewListenerFunc[E lavalink.Message](f func(p Player, e E)) EventListener {
 	return &listenerFunc[E]{f: f}
 }
@@ -19,3 +31,44 @@ func (l *listenerFunc[E]) OnEvent(p Player, e lavalink.Message) {
 		l.f(p, event)
 	}
 }
+
+// NewHandlerFunc returns an EventHandler which calls f for events of type E. If f returns true, listeners with a lower priority skip the event.
+func NewHandlerFunc[E lavalink.Message](f func(p Player, e E) bool) EventHandler {
+	return &handlerFunc[E]{f: f}
+}
+
+type handlerFunc[E lavalink.Message] struct {
+	f func(p Player, e E) bool
+}
+
+func (h *handlerFunc[E]) OnEvent(p Player, e lavalink.Message) {
+	h.HandleEvent(p, e)
+}
+
+func (h *handlerFunc[E]) HandleEvent(p Player, e lavalink.Message) bool {
+	if event, ok := e.(E); ok {
+		return h.f(p, event)
+	}
+	return false
+}
+
+type listenerEntry struct {
+	listener EventListener
+	priority int
+}
+
+type listenerHandle struct {
+	client  *clientImpl
+	entries []*listenerEntry
+}
+
+func (h *listenerHandle) Remove() {
+	h.client.removeListenerEntries(func(entry *listenerEntry) bool {
+		for _, e := range h.entries {
+			if e == entry {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,38 @@
+package disgolink
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenerPriorities(t *testing.T) {
+	client := newTestClient()
+
+	var calls []string
+	listener := func(name string) EventListener {
+		return NewListenerFunc(func(p Player, e lavalink.TrackEndEvent) {
+			calls = append(calls, name)
+		})
+	}
+	handler := func(name string) EventListener {
+		return NewHandlerFunc(func(p Player, e lavalink.TrackEndEvent) bool {
+			calls = append(calls, name)
+			return e.Reason == lavalink.TrackEndReasonFinished
+		})
+	}
+
+	client.AddListeners(listener("default"))
+	client.AddListenersWithPriority(10, handler("handler"), listener("same priority"))
+	client.AddListenersWithPriority(20, listener("first"))
+	handle := client.AddListenersWithPriority(10, listener("removed"))
+
+	client.EmitEvent(nil, lavalink.TrackEndEvent{Reason: lavalink.TrackEndReasonStopped})
+	assert.Equal(t, []string{"first", "handler", "same priority", "removed", "default"}, calls)
+
+	handle.Remove()
+	calls = nil
+	client.EmitEvent(nil, lavalink.TrackEndEvent{Reason: lavalink.TrackEndReasonFinished})
+	assert.Equal(t, []string{"first", "handler", "same priority"}, calls)
+}
@@ -0,0 +1,171 @@
+package disgolink
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// LoadBalancer selects the Node new players are created on.
+type LoadBalancer interface {
+	// SelectNode returns one of the given nodes for the guild. nodes only contains connected nodes which accept new players, is sorted by name and never empty.
+	// guildID is 0 if no specific guild is requested, for example when calling Client.BestNode.
+	SelectNode(nodes []Node, guildID snowflake.ID) Node
+}
+
+// LoadBalancerFunc is a function implementing LoadBalancer.
+type LoadBalancerFunc func(nodes []Node, guildID snowflake.ID) Node
+
+func (f LoadBalancerFunc) SelectNode(nodes []Node, guildID snowflake.ID) Node {
+	return f(nodes, guildID)
+}
+
+var (
+	_ LoadBalancer = (*PenaltyLoadBalancer)(nil)
+	_ LoadBalancer = (*RoundRobinLoadBalancer)(nil)
+	_ LoadBalancer = (*LeastPlayersLoadBalancer)(nil)
+	_ LoadBalancer = (*ConsistentHashLoadBalancer)(nil)
+)
+
+// NewPenaltyLoadBalancer returns a LoadBalancer which selects the node with the lowest Penalty.
+func NewPenaltyLoadBalancer() *PenaltyLoadBalancer {
+	return &PenaltyLoadBalancer{}
+}
+
+// PenaltyLoadBalancer selects the node with the lowest Penalty like the official Lavalink client does.
+type PenaltyLoadBalancer struct{}
+
+func (*PenaltyLoadBalancer) SelectNode(nodes []Node, _ snowflake.ID) Node {
+	var (
+		bestNode    Node
+		bestPenalty float64
+	)
+	for _, node := range nodes {
+		penalty := Penalty(node.Stats())
+		if bestNode == nil || penalty < bestPenalty {
+			bestNode = node
+			bestPenalty = penalty
+		}
+	}
+	return bestNode
+}
+
+// Penalty calculates how loaded a node is based on its playing players, cpu load and nulled & deficit frames.
+// A higher penalty means the node is more loaded.
+func Penalty(stats lavalink.Stats) float64 {
+	playerPenalty := float64(stats.PlayingPlayers)
+	cpuPenalty := math.Pow(1.05, 100*stats.CPU.SystemLoad)*10 - 10
+
+	var deficitFramePenalty, nullFramePenalty float64
+	if stats.FrameStats != nil {
+		// frame stats are collected over one minute, which equals to 3000 frames
+		deficitFramePenalty = math.Pow(1.03, 500*float64(stats.FrameStats.Deficit)/3000)*600 - 600
+		nullFramePenalty = (math.Pow(1.03, 500*float64(stats.FrameStats.Nulled)/3000)*300 - 300) * 2
+	}
+
+	return playerPenalty + cpuPenalty + deficitFramePenalty + nullFramePenalty
+}
+
+// NewRoundRobinLoadBalancer returns a LoadBalancer which selects the nodes one after another.
+func NewRoundRobinLoadBalancer() *RoundRobinLoadBalancer {
+	return &RoundRobinLoadBalancer{}
+}
+
+// RoundRobinLoadBalancer selects the nodes one after another.
+type RoundRobinLoadBalancer struct {
+	next atomic.Uint64
+}
+
+func (b *RoundRobinLoadBalancer) SelectNode(nodes []Node, _ snowflake.ID) Node {
+	return nodes[(b.next.Add(1)-1)%uint64(len(nodes))]
+}
+
+// NewLeastPlayersLoadBalancer returns a LoadBalancer which selects the node with the fewest players.
+func NewLeastPlayersLoadBalancer() *LeastPlayersLoadBalancer {
+	return &LeastPlayersLoadBalancer{}
+}
+
+// LeastPlayersLoadBalancer selects the node with the fewest players known to the Client.
+// Nodes without a Client are compared by the players reported in their Stats.
+type LeastPlayersLoadBalancer struct{}
+
+func (*LeastPlayersLoadBalancer) SelectNode(nodes []Node, _ snowflake.ID) Node {
+	count := func(node Node) int {
+		return node.Stats().Players
+	}
+	if client := nodes[0].Lavalink(); client != nil {
+		players := client.PlayerCounts()
+		count = func(node Node) int {
+			return players[node.Config().Name]
+		}
+	}
+
+	var (
+		bestNode    Node
+		bestPlayers int
+	)
+	for _, node := range nodes {
+		if players := count(node); bestNode == nil || players < bestPlayers {
+			bestNode = node
+			bestPlayers = players
+		}
+	}
+	return bestNode
+}
+
+// NewConsistentHashLoadBalancer returns a LoadBalancer which always selects the same node for a guild as long as the available nodes do not change.
+// replicas is the number of points each node gets on the hash ring, the more replicas the more evenly guilds are distributed. Defaults to 100.
+func NewConsistentHashLoadBalancer(replicas int) *ConsistentHashLoadBalancer {
+	if replicas <= 0 {
+		replicas = 100
+	}
+	return &ConsistentHashLoadBalancer{
+		replicas: replicas,
+	}
+}
+
+// ConsistentHashLoadBalancer maps guilds to nodes using a consistent hash ring.
+// When a node becomes unavailable only the guilds of that node are redistributed.
+type ConsistentHashLoadBalancer struct {
+	replicas int
+}
+
+func (b *ConsistentHashLoadBalancer) SelectNode(nodes []Node, guildID snowflake.ID) Node {
+	type point struct {
+		hash uint32
+		node Node
+	}
+
+	ring := make([]point, 0, len(nodes)*b.replicas)
+	for _, node := range nodes {
+		for i := 0; i < b.replicas; i++ {
+			ring = append(ring, point{
+				hash: hash(node.Config().Name + "#" + strconv.Itoa(i)),
+				node: node,
+			})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+
+	guildHash := hash(guildID.String())
+	i := sort.Search(len(ring), func(i int) bool {
+		return ring[i].hash >= guildHash
+	})
+	if i == len(ring) {
+		i = 0
+	}
+	return ring[i].node
+}
+
+func hash(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,91 @@
+package disgolink
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testNode struct {
+	Node
+	name     string
+	stats    lavalink.Stats
+	lavalink Client
+}
+
+func (n *testNode) Config() NodeConfig {
+	return NodeConfig{Name: n.name}
+}
+
+func (n *testNode) Stats() lavalink.Stats {
+	return n.stats
+}
+
+func (n *testNode) Lavalink() Client {
+	return n.lavalink
+}
+
+func TestPenaltyLoadBalancer_SelectNode(t *testing.T) {
+	idle := &testNode{name: "idle", stats: lavalink.Stats{PlayingPlayers: 2, CPU: lavalink.CPU{SystemLoad: 0.1}}}
+	busy := &testNode{name: "busy", stats: lavalink.Stats{PlayingPlayers: 1, CPU: lavalink.CPU{SystemLoad: 0.9}}}
+	lagging := &testNode{name: "lagging", stats: lavalink.Stats{PlayingPlayers: 1, CPU: lavalink.CPU{SystemLoad: 0.1}, FrameStats: &lavalink.FrameStats{Deficit: 300}}}
+
+	node := NewPenaltyLoadBalancer().SelectNode([]Node{busy, lagging, idle}, 0)
+	assert.Equal(t, "idle", node.Config().Name)
+}
+
+func TestRoundRobinLoadBalancer_SelectNode(t *testing.T) {
+	nodes := []Node{&testNode{name: "a"}, &testNode{name: "b"}, &testNode{name: "c"}}
+	lb := NewRoundRobinLoadBalancer()
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		names = append(names, lb.SelectNode(nodes, 0).Config().Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "a"}, names)
+}
+
+func TestLeastPlayersLoadBalancer_SelectNode(t *testing.T) {
+	client := New(snowflake.ID(1))
+	a, b := &testNode{name: "a", lavalink: client}, &testNode{name: "b", lavalink: client}
+	client.PlayerOnNode(a, 1)
+	client.PlayerOnNode(a, 2)
+	client.PlayerOnNode(b, 3)
+	client.PlayerOnNode(nil, 4)
+
+	lb := NewLeastPlayersLoadBalancer()
+	assert.Equal(t, "b", lb.SelectNode([]Node{a, b}, 0).Config().Name)
+
+	// selecting a node while iterating the players must not deadlock
+	client.ForPlayers(func(player Player) {
+		assert.Equal(t, "b", lb.SelectNode([]Node{a, b}, 0).Config().Name)
+	})
+}
+
+func TestLeastPlayersLoadBalancer_SelectNodeStats(t *testing.T) {
+	a := &testNode{name: "a", stats: lavalink.Stats{Players: 3}}
+	b := &testNode{name: "b", stats: lavalink.Stats{Players: 1}}
+
+	assert.Equal(t, "b", NewLeastPlayersLoadBalancer().SelectNode([]Node{a, b}, 0).Config().Name)
+}
+
+func TestConsistentHashLoadBalancer_SelectNode(t *testing.T) {
+	a, b, c := &testNode{name: "a"}, &testNode{name: "b"}, &testNode{name: "c"}
+	lb := NewConsistentHashLoadBalancer(0)
+
+	moved := 0
+	for guildID := snowflake.ID(1); guildID <= 1000; guildID++ {
+		before := lb.SelectNode([]Node{a, b, c}, guildID)
+		assert.Equal(t, before, lb.SelectNode([]Node{a, b, c}, guildID))
+
+		after := lb.SelectNode([]Node{a, b}, guildID)
+		if before != Node(c) {
+			assert.Equal(t, before, after, "guild %d was moved although its node is still available", guildID)
+		} else {
+			moved++
+		}
+	}
+	assert.NotZero(t, moved)
+}
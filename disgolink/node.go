@@ -7,10 +7,14 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/snowflake/v2"
 	"github.com/gorilla/websocket"
 )
 
@@ -24,7 +28,10 @@ const (
 	StatusDisconnected Status = "DISCONNECTED"
 )
 
-var ErrNodeAlreadyConnected = errors.New("node already connected")
+var (
+	ErrNodeAlreadyConnected = errors.New("node already connected")
+	ErrNodeClosed           = errors.New("node closed")
+)
 
 var _ Node = (*nodeImpl)(nil)
 
@@ -36,6 +43,10 @@ type Node interface {
 	Stats() lavalink.Stats
 	Status() Status
 	SessionID() string
+	// Latency returns the round trip time of the last websocket ping or 0 if no ping has been answered yet.
+	Latency() time.Duration
+	// StatsHistory returns the most recent lavalink.Stats of the node.
+	StatsHistory() *StatsHistory
 
 	Version(ctx context.Context) (string, error)
 	Info(ctx context.Context) (*lavalink.Info, error)
@@ -46,16 +57,37 @@ type Node interface {
 	DecodeTrack(ctx context.Context, encodedTrack string) (*lavalink.Track, error)
 	DecodeTracks(ctx context.Context, encodedTracks []string) ([]lavalink.Track, error)
 
+	RoutePlannerStatus(ctx context.Context) (*lavalink.RoutePlannerStatus, error)
+	FreeRoutePlannerAddress(ctx context.Context, address string) error
+	FreeAllRoutePlannerAddresses(ctx context.Context) error
+
 	Open(ctx context.Context) error
 	Close()
 }
 
 type NodeConfig struct {
-	Name      string `json:"name"`
-	Address   string `json:"address"`
-	Password  string `json:"password"`
-	Secure    bool   `json:"secure"`
-	SessionID string `json:"session_id"`
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	Password  string   `json:"password"`
+	Secure    bool     `json:"secure"`
+	SessionID string   `json:"session_id"`
+	Regions   []string `json:"regions"`
+	// Trace requests stack traces from Lavalink for all rest requests, which are put into lavalink.Error.Trace. See WithTrace.
+	Trace bool `json:"trace"`
+	// ReconnectPolicy decides when to reconnect the node. Defaults to DefaultReconnectPolicy.
+	ReconnectPolicy ReconnectPolicy `json:"-"`
+	// RestMiddlewares are called for all rest requests to the node after the ones configured with WithRestMiddlewares.
+	RestMiddlewares []RestMiddleware `json:"-"`
+}
+
+// HasRegion returns true if the node is tagged with the given region.
+func (c NodeConfig) HasRegion(region string) bool {
+	for _, r := range c.Regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
 }
 
 func (c NodeConfig) RestURL() string {
@@ -85,6 +117,30 @@ type nodeImpl struct {
 	conn   *websocket.Conn
 	connMu sync.Mutex
 
+	reconnectMu     sync.Mutex
+	cancelReconnect context.CancelFunc
+	closed          atomic.Bool
+
+	resuming      bool
+	resumeTimeout time.Duration
+
+	heartbeatInterval time.Duration
+	heartbeatTimeout  time.Duration
+	statsTimeout      time.Duration
+	latency           atomic.Int64
+	lastStats         atomic.Int64
+
+	statsHistory     *StatsHistory
+	statsStaleAfter  time.Duration
+	healthThresholds HealthThresholds
+	degraded         atomic.Bool
+
+	failoverGracePeriod time.Duration
+	failoverMu          sync.Mutex
+	failoverTimer       *time.Timer
+
+	// mu guards status, stats, sessionID and config.SessionID which are written by the connection goroutines.
+	mu        sync.RWMutex
 	status    Status
 	stats     lavalink.Stats
 	sessionID string
@@ -95,6 +151,8 @@ func (n *nodeImpl) Lavalink() Client {
 }
 
 func (n *nodeImpl) Config() NodeConfig {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.config
 }
 
@@ -103,17 +161,37 @@ func (n *nodeImpl) Rest() RestClient {
 }
 
 func (n *nodeImpl) Status() Status {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.status
 }
 
+func (n *nodeImpl) setStatus(status Status) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.status = status
+}
+
 func (n *nodeImpl) Stats() lavalink.Stats {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.stats
 }
 
+func (n *nodeImpl) StatsHistory() *StatsHistory {
+	return n.statsHistory
+}
+
 func (n *nodeImpl) SessionID() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.sessionID
 }
 
+func (n *nodeImpl) Latency() time.Duration {
+	return time.Duration(n.latency.Load())
+}
+
 func (n *nodeImpl) Version(ctx context.Context) (string, error) {
 	return n.rest.Version(ctx)
 }
@@ -123,15 +201,30 @@ func (n *nodeImpl) Info(ctx context.Context) (*lavalink.Info, error) {
 }
 
 func (n *nodeImpl) Update(ctx context.Context, update lavalink.SessionUpdate) error {
-	session, err := n.rest.UpdateSession(ctx, n.sessionID, update)
+	sessionID := n.SessionID()
+	session, err := n.rest.UpdateSession(ctx, sessionID, update)
 	if session != nil && session.Resuming {
-		n.config.SessionID = n.sessionID
+		n.mu.Lock()
+		n.config.SessionID = sessionID
+		n.mu.Unlock()
 	}
 	return err
 }
 
 func (n *nodeImpl) LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error) {
-	return n.rest.LoadTracks(ctx, identifier)
+	ctx, span := n.lavalink.Tracer().Start(ctx, "lavalink.load_tracks",
+		slog.String(AttrNodeName, n.config.Name),
+		slog.String(AttrIdentifier, identifier),
+	)
+	defer span.End()
+
+	result, err := n.rest.LoadTracks(ctx, identifier)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(slog.String(AttrLoadType, string(result.LoadType)))
+	return result, nil
 }
 
 func (n *nodeImpl) LoadTracksHandler(ctx context.Context, identifier string, handler AudioLoadResultHandler) {
@@ -160,12 +253,22 @@ func (n *nodeImpl) LoadTracksHandler(ctx context.Context, identifier string, han
 }
 
 func (n *nodeImpl) syncPlayers(ctx context.Context) error {
-	players, err := n.rest.Players(ctx, n.sessionID)
+	players, err := n.rest.Players(ctx, n.SessionID())
 	if err != nil {
 		return err
 	}
 
 	for _, player := range players {
+		// the player might have been moved to another node while this one was gone, the resumed session only holds a stale copy of it
+		if existing := n.lavalink.ExistingPlayer(player.GuildID); existing != nil {
+			if node := playerNode(existing); node != nil && node != Node(n) {
+				if err = n.rest.DestroyPlayer(ctx, n.SessionID(), player.GuildID); err != nil {
+					n.logger.ErrorContext(ctx, "failed to destroy stale player of resumed session", slog.Any("err", err), slog.Int64("guild_id", int64(player.GuildID)))
+				}
+				continue
+			}
+		}
+
 		p := n.lavalink.PlayerOnNode(n, player.GuildID)
 		if p == nil {
 			continue
@@ -184,23 +287,71 @@ func (n *nodeImpl) DecodeTracks(ctx context.Context, encodedTracks []string) ([]
 	return n.rest.DecodeTracks(ctx, encodedTracks)
 }
 
+func (n *nodeImpl) RoutePlannerStatus(ctx context.Context) (*lavalink.RoutePlannerStatus, error) {
+	return n.rest.RoutePlannerStatus(ctx)
+}
+
+func (n *nodeImpl) FreeRoutePlannerAddress(ctx context.Context, address string) error {
+	return n.rest.FreeRoutePlannerAddress(ctx, address)
+}
+
+func (n *nodeImpl) FreeAllRoutePlannerAddresses(ctx context.Context) error {
+	return n.rest.FreeAllRoutePlannerAddresses(ctx)
+}
+
 func (n *nodeImpl) Open(ctx context.Context) error {
-	return n.reconnectTry(ctx, 0, false)
+	n.closed.Store(false)
+	return n.connect(ctx, false)
 }
 
 func (n *nodeImpl) open(ctx context.Context, reconnecting bool) error {
+	ready, err := n.openConn(ctx, reconnecting)
+	if err != nil {
+		return err
+	}
+
+	n.Lavalink().ForPlugins(func(plugin Plugin) {
+		if pl, ok := plugin.(PluginEventHandler); ok {
+			pl.OnNodeOpen(n)
+		}
+	})
+	n.lavalink.EmitEvent(nil, NodeReadyEvent{
+		Node_:     n,
+		Resumed:   ready.Resumed,
+		SessionID: ready.SessionID,
+	})
+
+	if !ready.Resumed {
+		n.recreatePlayers(ctx)
+	}
+
+	return nil
+}
+
+// openConn connects to the node and starts listening. connMu is held until the connection is stored, so a node is never opened twice.
+func (n *nodeImpl) openConn(ctx context.Context, reconnecting bool) (*lavalink.ReadyMessage, error) {
 	n.logger.Debug("opening connection to node...")
 
+	// emit before taking connMu, so listeners can close the node
+	if !reconnecting {
+		n.lavalink.EmitEvent(nil, NodeConnectingEvent{
+			Node_: n,
+		})
+	}
+
 	n.connMu.Lock()
 	defer n.connMu.Unlock()
 	if n.conn != nil {
-		return ErrNodeAlreadyConnected
+		return nil, ErrNodeAlreadyConnected
+	}
+	if n.closed.Load() {
+		return nil, ErrNodeClosed
 	}
 
 	if reconnecting {
-		n.status = StatusReconnecting
+		n.setStatus(StatusReconnecting)
 	} else {
-		n.status = StatusConnecting
+		n.setStatus(StatusConnecting)
 	}
 
 	header := http.Header{
@@ -208,105 +359,407 @@ func (n *nodeImpl) open(ctx context.Context, reconnecting bool) error {
 		"User-Id":       []string{n.lavalink.UserID().String()},
 		"Client-Name":   []string{fmt.Sprintf("%s/%s", Name, Version)},
 	}
-	if n.config.SessionID != "" {
-		header.Add("Session-Id", n.config.SessionID)
+	resumeSessionID := n.Config().SessionID
+	if resumeSessionID != "" {
+		header.Add("Session-Id", resumeSessionID)
 	}
 
-	conn, _, err := websocket.DefaultDialer.DialContext(ctx, n.config.WsURL(), header)
+	conn, rs, err := websocket.DefaultDialer.DialContext(ctx, n.config.WsURL(), header)
 	if err != nil {
-		return err
+		return nil, newHandshakeError(rs, err)
 	}
 
 	_, data, err := conn.ReadMessage()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	message, err := lavalink.UnmarshalMessage(data)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal ready message. error: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal ready message. error: %w", err)
 	}
 	ready, ok := message.(lavalink.ReadyMessage)
 	if !ok {
-		return fmt.Errorf("expected ready message but got %T", message)
+		return nil, fmt.Errorf("expected ready message but got %T", message)
 	}
 
+	n.mu.Lock()
 	n.sessionID = ready.SessionID
-	if n.config.SessionID != "" {
+	n.mu.Unlock()
+	if resumeSessionID != "" {
 		if ready.Resumed {
-			n.logger.InfoContext(ctx, "successfully resumed session", slog.String("session_id", n.config.SessionID))
+			n.logger.InfoContext(ctx, "successfully resumed session", slog.String("session_id", resumeSessionID))
 			if err = n.syncPlayers(ctx); err != nil {
-				n.logger.Warn("failed to sync players: ", err)
+				n.logger.Warn("failed to sync players", slog.Any("err", err))
 			}
 		} else {
-			n.logger.Warn("failed to resume session", slog.String("session_id", n.config.SessionID))
+			n.logger.Warn("failed to resume session", slog.String("session_id", resumeSessionID))
+		}
+	}
+	if n.resuming {
+		if err = n.Update(ctx, lavalink.SessionUpdate{
+			Resuming: json.Ptr(true),
+			Timeout:  json.Ptr(int(n.resumeTimeout.Seconds())),
+		}); err != nil {
+			n.logger.ErrorContext(ctx, "failed to enable session resuming", slog.Any("err", err))
 		}
 	}
-	n.status = StatusConnected
+	n.setStatus(StatusConnected)
+	n.stopFailover()
 
 	conn.SetCloseHandler(func(code int, text string) error {
 		return nil
 	})
 
 	n.conn = conn
+	n.lastStats.Store(time.Now().UnixNano())
+
+	if n.heartbeatInterval > 0 {
+		_ = conn.SetReadDeadline(n.readDeadline())
+		conn.SetPongHandler(func(appData string) error {
+			if sent, err := strconv.ParseInt(appData, 10, 64); err == nil {
+				n.latency.Store(time.Now().UnixNano() - sent)
+			}
+			return conn.SetReadDeadline(n.readDeadline())
+		})
+	}
 
 	go n.listen(conn)
+	if n.heartbeatInterval > 0 || n.statsTimeout > 0 || n.statsStaleAfter > 0 {
+		go n.heartbeat(conn)
+	}
 
-	n.Lavalink().ForPlugins(func(plugin Plugin) {
-		if pl, ok := plugin.(PluginEventHandler); ok {
-			pl.OnNodeOpen(n)
+	return &ready, nil
+}
+
+// recreatePlayers sends the full state of all players of this node to the new session, so playback continues after the session could not be resumed.
+func (n *nodeImpl) recreatePlayers(ctx context.Context) {
+	var players []Player
+	n.lavalink.ForPlayers(func(player Player) {
+		if player.Node() == Node(n) {
+			players = append(players, player)
 		}
 	})
+	if len(players) == 0 {
+		return
+	}
 
-	return nil
+	event := PlayersRestoredEvent{
+		Node_:  n,
+		Failed: map[snowflake.ID]error{},
+	}
+	for _, player := range players {
+		if err := player.Recreate(ctx); err != nil {
+			n.logger.ErrorContext(ctx, "failed to recreate player", slog.Any("err", err), slog.Int64("guild_id", int64(player.GuildID())))
+			event.Failed[player.GuildID()] = err
+			continue
+		}
+		event.Restored = append(event.Restored, player.GuildID())
+	}
+	n.logger.InfoContext(ctx, "recreated players on new session", slog.Int("restored", len(event.Restored)), slog.Int("failed", len(event.Failed)))
+	n.lavalink.EmitEvent(nil, event)
 }
 
 func (n *nodeImpl) Close() {
+	n.closed.Store(true)
+	n.reconnectMu.Lock()
+	if n.cancelReconnect != nil {
+		n.cancelReconnect()
+		n.cancelReconnect = nil
+	}
+	n.reconnectMu.Unlock()
+	n.stopFailover()
+
+	n.disconnect(nil)
+}
+
+func (n *nodeImpl) disconnect(err error) {
 	n.Lavalink().ForPlugins(func(plugin Plugin) {
 		if pl, ok := plugin.(PluginEventHandler); ok {
 			pl.OnNodeClose(n)
 		}
 	})
-	n.status = StatusDisconnected
+	n.setStatus(StatusDisconnected)
+	n.connMu.Lock()
 	if n.conn != nil {
 		_ = n.conn.Close()
 		n.conn = nil
 	}
-
+	n.connMu.Unlock()
+	n.lavalink.EmitEvent(nil, NodeDisconnectedEvent{
+		Node_: n,
+		Err:   err,
+	})
 }
 
-func (n *nodeImpl) reconnectTry(ctx context.Context, try int, reconnecting bool) error {
-	delay := time.Duration(try) * 2 * time.Second
-	if delay > 30*time.Second {
-		delay = 30 * time.Second
+// connect opens the connection to the node and retries according to the ReconnectPolicy until it succeeds, the policy gives up or the context is done.
+// The first attempt of a new connection is made immediately.
+func (n *nodeImpl) connect(ctx context.Context, reconnecting bool) (err error) {
+	policy := n.config.ReconnectPolicy
+	if policy == nil {
+		policy = DefaultReconnectPolicy()
 	}
 
-	timer := time.NewTimer(delay)
-	defer timer.Stop()
-	select {
-	case <-ctx.Done():
-		timer.Stop()
-		return ctx.Err()
-	case <-timer.C:
+	var attempt int
+	if reconnecting {
+		var span Span
+		ctx, span = n.lavalink.Tracer().Start(ctx, "lavalink.node.reconnect", slog.String(AttrNodeName, n.config.Name))
+		defer func() {
+			span.SetAttributes(slog.Int(AttrAttempts, attempt))
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}()
 	}
 
-	if err := n.open(ctx, reconnecting); err != nil {
-		if errors.Is(err, ErrNodeAlreadyConnected) {
+	start := time.Now()
+	var lastErr error
+	for attempt = 1; ; attempt++ {
+		if reconnecting || attempt > 1 {
+			delay, ok := policy.NextDelay(attempt, time.Since(start))
+			if !ok {
+				attempt--
+				if reconnecting {
+					n.lavalink.EmitEvent(nil, NodeReconnectFailedEvent{
+						Node_:    n,
+						Attempts: attempt,
+						Err:      lastErr,
+					})
+				}
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, lastErr)
+			}
+			if reconnecting {
+				n.lavalink.EmitEvent(nil, NodeReconnectingEvent{
+					Node_:   n,
+					Attempt: attempt,
+					Delay:   delay,
+				})
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if n.closed.Load() {
+			return ErrNodeClosed
+		}
+		err := n.open(ctx, reconnecting)
+		if err == nil {
+			if reconnecting {
+				n.lavalink.EmitEvent(nil, NodeReconnectedEvent{
+					Node_:    n,
+					Attempts: attempt,
+				})
+			}
+			return nil
+		}
+		if errors.Is(err, ErrNodeAlreadyConnected) || errors.Is(err, ErrNodeClosed) {
 			return err
 		}
-		n.logger.ErrorContext(ctx, "failed to reconnect node", slog.Any("err", err), slog.Int("try", try))
-		n.status = StatusDisconnected
-		return n.reconnectTry(ctx, try+1, reconnecting)
+		n.setStatus(StatusDisconnected)
+		if !IsRetryable(err) {
+			n.logger.ErrorContext(ctx, "failed to connect to node, not retrying", slog.Any("err", err), slog.Int("attempt", attempt))
+			n.lavalink.EmitEvent(nil, NodeErrorEvent{
+				Node_: n,
+				Err:   err,
+			})
+			return err
+		}
+		n.logger.ErrorContext(ctx, "failed to connect to node", slog.Any("err", err), slog.Int("attempt", attempt))
+		lastErr = err
 	}
-	return nil
 }
 
-func (n *nodeImpl) reconnect() {
-	if err := n.reconnectTry(context.Background(), 0, true); err != nil {
+// startReconnect registers the cancel func of the reconnect before starting it, so a concurrent Close always stops it.
+func (n *nodeImpl) startReconnect() {
+	n.reconnectMu.Lock()
+	defer n.reconnectMu.Unlock()
+	if n.closed.Load() {
+		return
+	}
+	if n.cancelReconnect != nil {
+		n.cancelReconnect()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancelReconnect = cancel
+	go n.reconnect(ctx, cancel)
+}
+
+func (n *nodeImpl) reconnect(ctx context.Context, cancel context.CancelFunc) {
+	defer cancel()
+
+	// closing the node stops the reconnect, which is not an error
+	if err := n.connect(ctx, true); err != nil && !errors.Is(err, ErrNodeClosed) && !errors.Is(err, context.Canceled) {
 		n.logger.Error("failed to reopen node", slog.Any("err", err))
 	}
 }
 
+// scheduleFailover moves all players of this node to another node if it is still not connected after the failover grace period.
+func (n *nodeImpl) scheduleFailover() {
+	if n.failoverGracePeriod <= 0 {
+		return
+	}
+
+	n.failoverMu.Lock()
+	defer n.failoverMu.Unlock()
+	if n.failoverTimer != nil {
+		return
+	}
+	n.failoverTimer = time.AfterFunc(n.failoverGracePeriod, n.failover)
+}
+
+func (n *nodeImpl) stopFailover() {
+	n.failoverMu.Lock()
+	defer n.failoverMu.Unlock()
+	if n.failoverTimer != nil {
+		n.failoverTimer.Stop()
+		n.failoverTimer = nil
+	}
+}
+
+func (n *nodeImpl) failover() {
+	n.failoverMu.Lock()
+	n.failoverTimer = nil
+	n.failoverMu.Unlock()
+
+	if n.Status() == StatusConnected {
+		return
+	}
+
+	var players []Player
+	n.lavalink.ForPlayers(func(player Player) {
+		if player.Node() == Node(n) {
+			players = append(players, player)
+		}
+	})
+	if len(players) == 0 {
+		return
+	}
+
+	n.logger.Warn("node did not reconnect in time, moving players", slog.Duration("grace_period", n.failoverGracePeriod), slog.Int("players", len(players)))
+	for _, player := range players {
+		target := n.lavalink.BestNode()
+		if target == nil || target == Node(n) || target.Status() != StatusConnected {
+			n.logger.Error("no node available to move players to")
+			return
+		}
+
+		if err := player.MoveTo(context.Background(), target); err != nil {
+			n.logger.Error("failed to move player", slog.Any("err", err), slog.Int64("guild_id", int64(player.GuildID())), slog.String("target_node", target.Config().Name))
+		}
+	}
+}
+
+func (n *nodeImpl) readDeadline() time.Time {
+	return time.Now().Add(n.heartbeatInterval + n.heartbeatTimeout)
+}
+
+// heartbeat pings the node and checks that stats are received regularly. If the node does not respond in time the connection is
+// considered dead and the read deadline is moved into the past, so listen reconnects.
+func (n *nodeImpl) heartbeat(conn *websocket.Conn) {
+	defer n.logger.Debug("exiting heartbeat goroutine")
+
+	interval := n.heartbeatInterval
+	if interval <= 0 {
+		interval = n.statsTimeout
+	}
+	if interval <= 0 {
+		interval = n.statsStaleAfter
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastBackfill time.Time
+
+	for range ticker.C {
+		n.connMu.Lock()
+		sameConnection := n.conn == conn
+		n.connMu.Unlock()
+		if !sameConnection {
+			return
+		}
+
+		if n.statsTimeout > 0 {
+			if since := time.Since(time.Unix(0, n.lastStats.Load())); since > n.statsTimeout {
+				n.logger.Warn("did not receive stats in time, reconnecting", slog.Duration("since", since))
+				_ = conn.SetReadDeadline(time.Now())
+				return
+			}
+		}
+
+		if n.statsStaleAfter > 0 && time.Since(time.Unix(0, n.lastStats.Load())) > n.statsStaleAfter && time.Since(lastBackfill) > n.statsStaleAfter {
+			lastBackfill = time.Now()
+			go n.backfillStats()
+		}
+
+		if n.heartbeatInterval > 0 {
+			payload := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+			if err := conn.WriteControl(websocket.PingMessage, payload, time.Now().Add(n.heartbeatTimeout)); err != nil {
+				n.logger.Warn("failed to send ping, reconnecting", slog.Any("err", err))
+				_ = conn.SetReadDeadline(time.Now())
+				return
+			}
+		}
+	}
+}
+
+// backfillStats records the stats from the rest api when no lavalink.StatsMessage has been received for a while.
+func (n *nodeImpl) backfillStats() {
+	ctx, cancel := context.WithTimeout(context.Background(), n.statsStaleAfter)
+	defer cancel()
+
+	stats, err := n.rest.Stats(ctx)
+	if err != nil {
+		n.logger.Warn("failed to backfill stats", slog.Any("err", err))
+		return
+	}
+	if stats != nil {
+		n.recordStats(*stats)
+	}
+}
+
+// recordStats adds the stats to the history and emits NodeDegradedEvent or NodeRecoveredEvent when the HealthThresholds are crossed.
+func (n *nodeImpl) recordStats(stats lavalink.Stats) {
+	n.mu.Lock()
+	n.stats = stats
+	n.mu.Unlock()
+	n.statsHistory.add(StatsSample{
+		Stats: stats,
+		Time:  time.Now(),
+	})
+	if !n.healthThresholds.Enabled() {
+		return
+	}
+
+	summary := n.statsHistory.Summary()
+	reasons := n.healthThresholds.Check(summary)
+	if len(reasons) > 0 {
+		if n.degraded.CompareAndSwap(false, true) {
+			n.logger.Warn("node degraded", slog.Any("reasons", reasons))
+			n.lavalink.EmitEvent(nil, NodeDegradedEvent{
+				Node_:   n,
+				Summary: summary,
+				Reasons: reasons,
+			})
+		}
+		return
+	}
+	if n.degraded.CompareAndSwap(true, false) {
+		n.logger.Info("node recovered")
+		n.lavalink.EmitEvent(nil, NodeRecoveredEvent{
+			Node_:   n,
+			Summary: summary,
+		})
+	}
+}
+
 func (n *nodeImpl) listen(conn *websocket.Conn) {
 	defer n.logger.Debug("exiting listen goroutine")
 loop:
@@ -321,18 +774,25 @@ loop:
 				return
 			}
 
-			reconnect := true
-			if errors.Is(err, net.ErrClosed) {
-				reconnect = false
+			// the connection was closed by us if it is net.ErrClosed, all close codes sent by the node are retryable
+			reconnect := !errors.Is(err, net.ErrClosed)
+			if reconnect {
+				err = newCloseError(err)
+				n.logger.Warn("lost connection to node", slog.Any("err", err))
 			}
 
-			n.Close()
+			n.disconnect(err)
 			if reconnect {
-				go n.reconnect()
+				n.scheduleFailover()
+				n.startReconnect()
 			}
 			break loop
 		}
 
+		if n.heartbeatInterval > 0 {
+			_ = conn.SetReadDeadline(n.readDeadline())
+		}
+
 		n.logger.Debug("received message", slog.String("data", string(data)))
 
 		n.Lavalink().ForPlugins(func(plugin Plugin) {
@@ -356,12 +816,14 @@ loop:
 			})
 
 		case lavalink.StatsMessage:
-			n.stats = lavalink.Stats(message)
+			n.lastStats.Store(time.Now().UnixNano())
+			n.recordStats(lavalink.Stats(message))
 			n.lavalink.EmitEvent(nil, m)
 
 		case lavalink.PlayerUpdateMessage:
 			player := n.lavalink.ExistingPlayer(message.GuildID)
-			if player == nil {
+			// a player which was moved to another node must not receive the messages of its previous node
+			if player == nil || playerNode(player) != Node(n) {
 				continue
 			}
 			player.OnPlayerUpdate(message.State)
@@ -369,11 +831,17 @@ loop:
 
 		case lavalink.Event:
 			player := n.lavalink.ExistingPlayer(message.GuildID())
-			if player == nil {
+			if player == nil || playerNode(player) != Node(n) {
 				continue
 			}
+			_, span := n.lavalink.Tracer().Start(traceContext(player), "lavalink.event",
+				slog.String(AttrNodeName, n.config.Name),
+				slog.String(AttrGuildID, message.GuildID().String()),
+				slog.String(AttrEventType, string(message.Type())),
+			)
 			player.OnEvent(message)
 			n.lavalink.EmitEvent(player, m)
+			span.End()
 		}
 	}
 }
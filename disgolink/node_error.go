@@ -0,0 +1,95 @@
+package disgolink
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrVersionMismatch  = errors.New("lavalink version not supported")
+	ErrNodeShuttingDown = errors.New("node is shutting down")
+	ErrNodeUnavailable  = errors.New("node unavailable")
+)
+
+// ConnectionError is returned when the websocket handshake with a Node fails or the connection is closed by the Node.
+// Use errors.Is to check for ErrUnauthorized, ErrVersionMismatch, ErrNodeShuttingDown or ErrNodeUnavailable.
+type ConnectionError struct {
+	// StatusCode is the http status code of a failed handshake or 0.
+	StatusCode int
+	// CloseCode is the websocket close code sent by the node or 0.
+	CloseCode int
+	// Reason is the websocket close reason sent by the node.
+	Reason string
+	Err    error
+}
+
+func (e *ConnectionError) Error() string {
+	if e.CloseCode != 0 {
+		return fmt.Sprintf("connection closed with code %d (%s): %s", e.CloseCode, e.Reason, e.Err)
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("handshake failed with status %d: %s", e.StatusCode, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether connecting to a Node again could succeed after the given error.
+// Wrong passwords and unsupported Lavalink versions are not retryable.
+func IsRetryable(err error) bool {
+	return !errors.Is(err, ErrUnauthorized) && !errors.Is(err, ErrVersionMismatch)
+}
+
+// newHandshakeError classifies the http response of a failed websocket handshake.
+func newHandshakeError(rs *http.Response, err error) error {
+	if rs == nil {
+		return err
+	}
+
+	var sentinel error
+	switch {
+	case rs.StatusCode == http.StatusUnauthorized || rs.StatusCode == http.StatusForbidden:
+		sentinel = ErrUnauthorized
+	case rs.StatusCode == http.StatusNotFound:
+		// lavalink versions before v4 do not serve /v4/websocket
+		sentinel = ErrVersionMismatch
+	case rs.StatusCode >= http.StatusInternalServerError:
+		sentinel = ErrNodeUnavailable
+	default:
+		sentinel = err
+	}
+	return &ConnectionError{
+		StatusCode: rs.StatusCode,
+		Err:        sentinel,
+	}
+}
+
+// newCloseError classifies the error returned when reading from a closed websocket connection.
+func newCloseError(err error) error {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return err
+	}
+
+	var sentinel error
+	switch closeErr.Code {
+	case websocket.CloseGoingAway, websocket.CloseServiceRestart:
+		sentinel = ErrNodeShuttingDown
+	case websocket.CloseTryAgainLater, websocket.CloseInternalServerErr:
+		sentinel = ErrNodeUnavailable
+	default:
+		sentinel = err
+	}
+	return &ConnectionError{
+		CloseCode: closeErr.Code,
+		Reason:    closeErr.Text,
+		Err:       sentinel,
+	}
+}
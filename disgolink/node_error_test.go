@@ -0,0 +1,57 @@
+package disgolink
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCloseError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		retryable bool
+	}{
+		{name: "going away", err: &websocket.CloseError{Code: websocket.CloseGoingAway}, sentinel: ErrNodeShuttingDown, retryable: true},
+		{name: "service restart", err: &websocket.CloseError{Code: websocket.CloseServiceRestart}, sentinel: ErrNodeShuttingDown, retryable: true},
+		{name: "try again later", err: &websocket.CloseError{Code: websocket.CloseTryAgainLater}, sentinel: ErrNodeUnavailable, retryable: true},
+		{name: "internal server error", err: &websocket.CloseError{Code: websocket.CloseInternalServerErr}, sentinel: ErrNodeUnavailable, retryable: true},
+		{name: "policy violation", err: &websocket.CloseError{Code: websocket.ClosePolicyViolation}, retryable: true},
+		{name: "abnormal closure", err: &websocket.CloseError{Code: websocket.CloseAbnormalClosure}, retryable: true},
+		{name: "no close error", err: errors.New("read: connection reset by peer"), retryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newCloseError(tt.err)
+			if tt.sentinel != nil {
+				assert.ErrorIs(t, err, tt.sentinel)
+			}
+			assert.Equal(t, tt.retryable, IsRetryable(err))
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "unauthorized", err: ErrUnauthorized, retryable: false},
+		{name: "version mismatch", err: ErrVersionMismatch, retryable: false},
+		{name: "wrapped unauthorized", err: fmt.Errorf("giving up: %w", &ConnectionError{StatusCode: http.StatusUnauthorized, Err: ErrUnauthorized}), retryable: false},
+		{name: "shutting down", err: ErrNodeShuttingDown, retryable: true},
+		{name: "unavailable", err: &ConnectionError{StatusCode: http.StatusBadGateway, Err: ErrNodeUnavailable}, retryable: true},
+		{name: "other", err: errors.New("dial tcp: connection refused"), retryable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.retryable, IsRetryable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package disgolink
+
+import (
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+const (
+	OpNode lavalink.Op = "node" // not actually sent by lavalink
+)
+
+// NodeEvent is emitted by disgolink itself for a specific Node. It is passed to EventListener(s) without a Player.
+type NodeEvent interface {
+	lavalink.Message
+	Node() Node
+}
+
+// NodeConnectingEvent is emitted when a Node starts connecting for the first time. Reconnects emit NodeReconnectingEvent instead.
+type NodeConnectingEvent struct {
+	Node_ Node
+}
+
+func (NodeConnectingEvent) Op() lavalink.Op { return OpNode }
+func (e NodeConnectingEvent) Node() Node    { return e.Node_ }
+
+// NodeReadyEvent is emitted when a Node received the lavalink.ReadyMessage and is connected.
+type NodeReadyEvent struct {
+	Node_     Node
+	Resumed   bool
+	SessionID string
+}
+
+func (NodeReadyEvent) Op() lavalink.Op { return OpNode }
+func (e NodeReadyEvent) Node() Node    { return e.Node_ }
+
+// NodeDisconnectedEvent is emitted when the connection of a Node is closed. Err is nil if the Node was closed on purpose.
+type NodeDisconnectedEvent struct {
+	Node_ Node
+	Err   error
+}
+
+func (NodeDisconnectedEvent) Op() lavalink.Op { return OpNode }
+func (e NodeDisconnectedEvent) Node() Node    { return e.Node_ }
+
+// NodeRemovedEvent is emitted after a Node has been removed from the Client.
+type NodeRemovedEvent struct {
+	Node_ Node
+}
+
+func (NodeRemovedEvent) Op() lavalink.Op { return OpNode }
+func (e NodeRemovedEvent) Node() Node    { return e.Node_ }
+
+// PlayersRestoredEvent is emitted after a Node could not resume its session and its players have been recreated on the new session.
+type PlayersRestoredEvent struct {
+	Node_    Node
+	Restored []snowflake.ID
+	Failed   map[snowflake.ID]error
+}
+
+func (PlayersRestoredEvent) Op() lavalink.Op { return OpNode }
+func (e PlayersRestoredEvent) Node() Node    { return e.Node_ }
+
+// NodeReconnectingEvent is emitted before a Node tries to reconnect after the given delay.
+type NodeReconnectingEvent struct {
+	Node_   Node
+	Attempt int
+	Delay   time.Duration
+}
+
+func (NodeReconnectingEvent) Op() lavalink.Op { return OpNode }
+func (e NodeReconnectingEvent) Node() Node    { return e.Node_ }
+
+// NodeReconnectedEvent is emitted after a Node successfully reconnected.
+type NodeReconnectedEvent struct {
+	Node_    Node
+	Attempts int
+}
+
+func (NodeReconnectedEvent) Op() lavalink.Op { return OpNode }
+func (e NodeReconnectedEvent) Node() Node    { return e.Node_ }
+
+// NodeReconnectFailedEvent is emitted when the ReconnectPolicy of a Node gives up. Err is the error of the last attempt.
+type NodeReconnectFailedEvent struct {
+	Node_    Node
+	Attempts int
+	Err      error
+}
+
+func (NodeReconnectFailedEvent) Op() lavalink.Op { return OpNode }
+func (e NodeReconnectFailedEvent) Node() Node    { return e.Node_ }
+
+// NodeErrorEvent is emitted when a Node fails with an error which is not retryable, like a wrong password. See IsRetryable.
+type NodeErrorEvent struct {
+	Node_ Node
+	Err   error
+}
+
+func (NodeErrorEvent) Op() lavalink.Op { return OpNode }
+func (e NodeErrorEvent) Node() Node    { return e.Node_ }
+
+// RestRetryEvent is emitted before a failed rest request to a Node is retried after the given delay.
+type RestRetryEvent struct {
+	Node_      Node
+	Method     string
+	Path       string
+	Attempt    int
+	Delay      time.Duration
+	StatusCode int
+	Err        error
+}
+
+func (RestRetryEvent) Op() lavalink.Op { return OpNode }
+func (e RestRetryEvent) Node() Node    { return e.Node_ }
+
+// NodeDegradedEvent is emitted when the StatsSummary of a Node exceeds the HealthThresholds. See WithHealthThresholds.
+type NodeDegradedEvent struct {
+	Node_   Node
+	Summary StatsSummary
+	Reasons []HealthReason
+}
+
+func (NodeDegradedEvent) Op() lavalink.Op { return OpNode }
+func (e NodeDegradedEvent) Node() Node    { return e.Node_ }
+
+// NodeRecoveredEvent is emitted when the StatsSummary of a degraded Node is within the HealthThresholds again.
+type NodeRecoveredEvent struct {
+	Node_   Node
+	Summary StatsSummary
+}
+
+func (NodeRecoveredEvent) Op() lavalink.Op { return OpNode }
+func (e NodeRecoveredEvent) Node() Node    { return e.Node_ }
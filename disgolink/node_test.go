@@ -0,0 +1,227 @@
+package disgolink
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLavalink is a websocket server which sends a ready message to every connection and hands the connection to the test.
+type testLavalink struct {
+	*httptest.Server
+	conns       chan *websocket.Conn
+	connections atomic.Int32
+	// silent connections do not read, so pings are never answered
+	silent atomic.Bool
+}
+
+func newTestLavalink(tb testing.TB) *testLavalink {
+	l := &testLavalink{
+		conns: make(chan *websocket.Conn, 10),
+	}
+	var upgrader websocket.Upgrader
+	l.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		n := l.connections.Add(1)
+		silent := l.silent.Load()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"op":"ready","resumed":false,"sessionId":"session-%d"}`, n)))
+		l.conns <- conn
+		if silent {
+			return
+		}
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	tb.Cleanup(l.Close)
+	return l
+}
+
+func (l *testLavalink) nodeConfig() NodeConfig {
+	return NodeConfig{
+		Name:    "test",
+		Address: strings.TrimPrefix(l.URL, "http://"),
+		ReconnectPolicy: &ExponentialBackoff{
+			MinDelay:   10 * time.Millisecond,
+			MaxDelay:   10 * time.Millisecond,
+			Multiplier: 1,
+		},
+	}
+}
+
+func (l *testLavalink) nextConn(tb testing.TB) *websocket.Conn {
+	select {
+	case conn := <-l.conns:
+		tb.Cleanup(func() { _ = conn.Close() })
+		return conn
+	case <-time.After(5 * time.Second):
+		tb.Fatal("timed out waiting for a connection")
+		return nil
+	}
+}
+
+func newNodeEventRecorder(opts ...ConfigOpt) (*clientImpl, chan NodeEvent) {
+	events := make(chan NodeEvent, 100)
+	client := newTestClient(append(opts, WithListenerFunc(func(_ Player, event NodeEvent) {
+		events <- event
+	}))...)
+	return client, events
+}
+
+// expectNodeEvents waits for node events of the given types in the given order.
+func expectNodeEvents(tb testing.TB, events chan NodeEvent, expected ...NodeEvent) {
+	tb.Helper()
+	for _, e := range expected {
+		select {
+		case event := <-events:
+			require.IsType(tb, e, event)
+		case <-time.After(5 * time.Second):
+			tb.Fatalf("timed out waiting for %T", e)
+		}
+	}
+}
+
+func TestNode_Lifecycle(t *testing.T) {
+	server := newTestLavalink(t)
+	client, events := newNodeEventRecorder(WithHeartbeat(0, 0))
+
+	node, err := client.AddNode(context.Background(), server.nodeConfig())
+	require.NoError(t, err)
+	expectNodeEvents(t, events, NodeConnectingEvent{}, NodeReadyEvent{})
+	assert.Equal(t, StatusConnected, node.Status())
+	assert.Equal(t, "session-1", node.SessionID())
+
+	conn := server.nextConn(t)
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, "restarting"))
+	_ = conn.Close()
+	expectNodeEvents(t, events, NodeDisconnectedEvent{}, NodeReconnectingEvent{}, NodeReadyEvent{}, NodeReconnectedEvent{})
+	server.nextConn(t)
+	assert.Equal(t, StatusConnected, node.Status())
+	assert.Equal(t, "session-2", node.SessionID())
+
+	client.RemoveNode("test")
+	expectNodeEvents(t, events, NodeDisconnectedEvent{}, NodeRemovedEvent{})
+	assert.Equal(t, StatusDisconnected, node.Status())
+	assert.Nil(t, client.Node("test"))
+
+	// a removed node must not reconnect
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), server.connections.Load())
+	assert.Empty(t, events)
+}
+
+func TestNode_Heartbeat(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		server := newTestLavalink(t)
+		server.silent.Store(true)
+		client, events := newNodeEventRecorder(WithHeartbeat(20*time.Millisecond, 20*time.Millisecond))
+		defer client.Close()
+
+		_, err := client.AddNode(context.Background(), server.nodeConfig())
+		require.NoError(t, err)
+		expectNodeEvents(t, events, NodeConnectingEvent{}, NodeReadyEvent{})
+		server.nextConn(t)
+		server.silent.Store(false)
+
+		// the pings of the first connection are not answered, so it times out and the node reconnects
+		expectNodeEvents(t, events, NodeDisconnectedEvent{}, NodeReconnectingEvent{}, NodeReadyEvent{}, NodeReconnectedEvent{})
+		server.nextConn(t)
+	})
+
+	t.Run("zero timeout", func(t *testing.T) {
+		server := newTestLavalink(t)
+		client, events := newNodeEventRecorder(WithHeartbeat(10*time.Millisecond, 0))
+		defer client.Close()
+
+		_, err := client.AddNode(context.Background(), server.nodeConfig())
+		require.NoError(t, err)
+		expectNodeEvents(t, events, NodeConnectingEvent{}, NodeReadyEvent{})
+		server.nextConn(t)
+
+		// answered pings must keep the connection alive
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, int32(1), server.connections.Load())
+		assert.Empty(t, events)
+	})
+}
+
+func TestNode_IgnoresMovedPlayers(t *testing.T) {
+	server := newTestLavalink(t)
+	guildIDs := make(chan snowflake.ID, 10)
+	client := newTestClient(WithHeartbeat(0, 0), WithListenerFunc(func(_ Player, event lavalink.TrackStuckEvent) {
+		guildIDs <- event.GuildID()
+	}))
+	defer client.Close()
+
+	node, err := client.AddNode(context.Background(), server.nodeConfig())
+	require.NoError(t, err)
+	conn := server.nextConn(t)
+
+	client.PlayerOnNode(&testNode{name: "other"}, 1)
+	client.PlayerOnNode(node, 2)
+	for _, guildID := range []string{"1", "2"} {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"op":"event","type":"TrackStuckEvent","guildId":"`+guildID+`","track":`+testTrackJSON+`,"thresholdMs":1000}`)))
+	}
+
+	select {
+	case guildID := <-guildIDs:
+		assert.Equal(t, snowflake.ID(2), guildID, "the player moved to another node should not receive the event")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+}
+
+func TestNode_SyncPlayersSkipsMovedPlayers(t *testing.T) {
+	var deleted atomic.Value
+	node := newTestNodeWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted.Store(r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[" + testPlayerJSON + "]"))
+	})
+	guildID := snowflake.ID(817327181659111454)
+	player := node.lavalink.PlayerOnNode(&testNode{name: "other"}, guildID)
+
+	require.NoError(t, node.syncPlayers(context.Background()))
+	assert.Nil(t, player.Track(), "the stale state of the resumed session should not be restored")
+	assert.Equal(t, "/v4/sessions/session/players/817327181659111454", deleted.Load())
+}
+
+func TestNode_CloseWhileConnecting(t *testing.T) {
+	server := newTestLavalink(t)
+	client := newTestClient(WithHeartbeat(0, 0), WithListenerFunc(func(_ Player, event NodeConnectingEvent) {
+		event.Node().Close()
+	}))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.AddNode(context.Background(), server.nodeConfig())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, ErrNodeClosed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing the node from a NodeConnectingEvent listener deadlocked")
+	}
+	assert.Zero(t, server.connections.Load())
+}
@@ -3,7 +3,9 @@ package disgolink
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/disgoorg/disgolink/v3/lavalink"
@@ -22,8 +24,15 @@ type Player interface {
 	Volume() int
 	Filters() lavalink.Filters
 
+	// Update sends the update to the node. Update, Destroy, MoveTo, Recreate and voice updates of a player are processed one at a time in call order.
+	// Use WithMerge to merge rapid updates of the same kind.
 	Update(ctx context.Context, opts ...lavalink.PlayerUpdateOpt) error
 	Destroy(ctx context.Context) error
+	// MoveTo creates the player with its current track, position, volume, paused state, filters and voice state on the given node and then destroys it on its
+	// previous node if that node is still connected. If creating the player fails it stays on its previous node.
+	MoveTo(ctx context.Context, node Node) error
+	// Recreate sends the full state of the player to its node. This is used when the node could not resume its session.
+	Recreate(ctx context.Context) error
 
 	Lavalink() Client
 	Node() Node
@@ -42,15 +51,20 @@ func NewPlayer(logger *slog.Logger, lavalink Client, node Node, guildID snowflak
 		node:     node,
 		guildID:  guildID,
 		volume:   100,
+
+		queuedUpdates: map[updateKind]*queuedUpdate{},
 	}
 }
 
 type playerImpl struct {
 	logger   *slog.Logger
-	node     Node
 	lavalink Client
+	guildID  snowflake.ID
 
-	guildID   snowflake.ID
+	// mu guards all fields below, which are written by the node, voice callbacks and updates concurrently.
+	// It is never held while sending requests or calling listeners and plugins.
+	mu        sync.RWMutex
+	node      Node
 	channelID *snowflake.ID
 	track     *lavalink.Track
 	volume    int
@@ -58,6 +72,26 @@ type playerImpl struct {
 	state     lavalink.PlayerState
 	voice     lavalink.VoiceState
 	filters   lavalink.Filters
+
+	// traceCtx is the context of the last update, which spans of events caused by it are started with.
+	traceCtx context.Context
+
+	// queue serializes updates, destroys, moves and voice updates, so their responses are applied in call order.
+	queue         opQueue
+	queuedMu      sync.Mutex
+	queuedUpdates map[updateKind]*queuedUpdate
+}
+
+// traceContext returns the context of the last update of the player or context.Background.
+func traceContext(player Player) context.Context {
+	if p, ok := player.(*playerImpl); ok {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		if p.traceCtx != nil {
+			return p.traceCtx
+		}
+	}
+	return context.Background()
 }
 
 func (p *playerImpl) GuildID() snowflake.ID {
@@ -65,18 +99,31 @@ func (p *playerImpl) GuildID() snowflake.ID {
 }
 
 func (p *playerImpl) ChannelID() *snowflake.ID {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.channelID
 }
 
 func (p *playerImpl) Track() *lavalink.Track {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.track
 }
 
 func (p *playerImpl) Paused() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.paused
 }
 
 func (p *playerImpl) Position() lavalink.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.position()
+}
+
+// position must be called with mu held.
+func (p *playerImpl) position() lavalink.Duration {
 	if p.track == nil {
 		return 0
 	}
@@ -94,30 +141,131 @@ func (p *playerImpl) Position() lavalink.Duration {
 }
 
 func (p *playerImpl) State() lavalink.PlayerState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.state
 }
 
 func (p *playerImpl) Volume() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.volume
 }
 
 func (p *playerImpl) Filters() lavalink.Filters {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.filters
 }
 
-func (p *playerImpl) Update(ctx context.Context, opts ...lavalink.PlayerUpdateOpt) error {
+func (p *playerImpl) currentNode() Node {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.node
+}
+
+// bindNode sets the node of a player which has none yet, without creating the player on it.
+func (p *playerImpl) bindNode(node Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.node == nil {
-		return ErrPlayerNoNode
+		p.node = node
+	}
+}
+
+// playerNode returns the node of the player without selecting one if it has none yet.
+func playerNode(player Player) Node {
+	if p, ok := player.(*playerImpl); ok {
+		return p.currentNode()
 	}
+	return player.Node()
+}
 
+func (p *playerImpl) Update(ctx context.Context, opts ...lavalink.PlayerUpdateOpt) error {
 	update := lavalink.DefaultPlayerUpdate()
 	update.Apply(opts)
 
-	updatedPlayer, err := p.node.Rest().UpdatePlayer(ctx, p.node.SessionID(), p.guildID, *update)
+	if mergeEnabled(ctx) {
+		return p.mergeUpdate(ctx, *update)
+	}
+
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return p.update(ctx, *update)
+}
+
+// mergeUpdate queues the update and supersedes a queued update of the same kind which has not been sent yet. See WithMerge.
+func (p *playerImpl) mergeUpdate(ctx context.Context, update lavalink.PlayerUpdate) error {
+	kind := updateKindOf(update)
+	queued := newQueuedUpdate()
+	p.queuedMu.Lock()
+	if prev, ok := p.queuedUpdates[kind]; ok {
+		prev.next = queued
+		close(prev.superseded)
+	}
+	p.queuedUpdates[kind] = queued
+	p.queuedMu.Unlock()
+
+	release, err := p.queue.acquire(ctx, queued.superseded)
+
+	p.queuedMu.Lock()
+	if p.queuedUpdates[kind] == queued {
+		delete(p.queuedUpdates, kind)
+	}
+	next := queued.next
+	p.queuedMu.Unlock()
+
+	if next != nil {
+		// a newer update of the same kind will be sent instead, so return its result
+		if release != nil {
+			release()
+		}
+		select {
+		case <-next.done:
+			err = next.err
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		queued.finish(err)
+		return err
+	}
+	if err != nil {
+		queued.finish(err)
+		return err
+	}
+
+	err = p.update(ctx, update)
+	release()
+	queued.finish(err)
+	return err
+}
+
+// update sends the update and applies the response. It must be called with a turn of the queue.
+func (p *playerImpl) update(ctx context.Context, update lavalink.PlayerUpdate) error {
+	node := p.currentNode()
+	if node == nil {
+		return ErrPlayerNoNode
+	}
+
+	ctx, span := p.lavalink.Tracer().Start(ctx, "lavalink.player.update",
+		slog.String(AttrNodeName, node.Config().Name),
+		slog.String(AttrGuildID, p.guildID.String()),
+	)
+	defer span.End()
+	p.mu.Lock()
+	p.traceCtx = context.WithoutCancel(ctx)
+	p.mu.Unlock()
+
+	updatedPlayer, err := node.Rest().UpdatePlayer(ctx, node.SessionID(), p.guildID, update)
 	if err != nil {
+		span.RecordError(err)
 		return err
 	}
 
+	p.mu.Lock()
 	p.track = updatedPlayer.Track
 	if updatedPlayer.Track != nil {
 		p.state.Position = updatedPlayer.Track.Info.Position
@@ -131,8 +279,8 @@ func (p *playerImpl) Update(ctx context.Context, opts ...lavalink.PlayerUpdateOp
 	p.filters = updatedPlayer.Filters
 
 	// dispatch artificial player resume/pause event
+	var event lavalink.Event
 	if update.Paused != nil {
-		var event lavalink.Event
 		if p.paused && !*update.Paused {
 			event = lavalink.PlayerResumeEvent{
 				GuildID_: p.guildID,
@@ -143,18 +291,29 @@ func (p *playerImpl) Update(ctx context.Context, opts ...lavalink.PlayerUpdateOp
 			}
 		}
 		p.paused = updatedPlayer.Paused
-		go p.OnEvent(event)
+	}
+	p.mu.Unlock()
+
+	if event != nil {
+		p.OnEvent(event)
 	}
 
 	return nil
 }
 
 func (p *playerImpl) Destroy(ctx context.Context) error {
-	if p.node == nil {
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// read the node within the turn, a queued move might have changed it
+	node := p.currentNode()
+	if node == nil {
+		release()
 		return ErrPlayerNoNode
 	}
-
-	err := p.node.Rest().DestroyPlayer(ctx, p.node.SessionID(), p.guildID)
+	err = node.Rest().DestroyPlayer(ctx, node.SessionID(), p.guildID)
+	release()
 	if err != nil {
 		return err
 	}
@@ -170,9 +329,120 @@ func (p *playerImpl) Destroy(ctx context.Context) error {
 	return nil
 }
 
+func (p *playerImpl) MoveTo(ctx context.Context, node Node) error {
+	if node == nil {
+		return ErrPlayerNoNode
+	}
+
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	from, err := p.moveTo(ctx, node)
+	release()
+	if err != nil || from == node {
+		return err
+	}
+
+	p.lavalink.ForPlugins(func(plugin Plugin) {
+		if pl, ok := plugin.(PluginMoveHandler); ok {
+			pl.OnMovePlayer(p, from, node)
+		}
+	})
+	p.lavalink.EmitEvent(p, PlayerMoveEvent{
+		GuildID_: p.guildID,
+		From:     from,
+		To:       node,
+	})
+	return nil
+}
+
+// moveTo moves the player and returns the node it was on before. It must be called with a turn of the queue.
+func (p *playerImpl) moveTo(ctx context.Context, node Node) (Node, error) {
+	from := p.currentNode()
+	if from == node {
+		return from, nil
+	}
+
+	p.mu.Lock()
+	p.node = node
+	p.mu.Unlock()
+	// create the player on the new node first, so it keeps playing on the previous node if that fails
+	if err := p.recreate(ctx); err != nil {
+		p.mu.Lock()
+		p.node = from
+		p.mu.Unlock()
+		return from, fmt.Errorf("failed to create player on node %s: %w", node.Config().Name, err)
+	}
+
+	// the player can only be destroyed if the previous node is still reachable
+	if from != nil && from.Status() == StatusConnected {
+		if err := from.Rest().DestroyPlayer(ctx, from.SessionID(), p.guildID); err != nil {
+			p.logger.ErrorContext(ctx, "error while destroying moved player on previous node", slog.String("node", from.Config().Name), slog.Any("err", err))
+		}
+	}
+	return from, nil
+}
+
+func (p *playerImpl) Recreate(ctx context.Context) error {
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return p.recreate(ctx)
+}
+
+// recreate must be called with a turn of the queue.
+func (p *playerImpl) recreate(ctx context.Context) error {
+	p.mu.RLock()
+	node := p.node
+	update := p.fullUpdate()
+	p.mu.RUnlock()
+	if node == nil {
+		return ErrPlayerNoNode
+	}
+
+	updatedPlayer, err := node.Rest().UpdatePlayer(ctx, node.SessionID(), p.guildID, update)
+	if err != nil {
+		return err
+	}
+	p.Restore(*updatedPlayer)
+	return nil
+}
+
+// fullUpdate returns a lavalink.PlayerUpdate which recreates the current state of the player. It must be called with mu held.
+func (p *playerImpl) fullUpdate() lavalink.PlayerUpdate {
+	opts := []lavalink.PlayerUpdateOpt{
+		lavalink.WithVolume(p.volume),
+		lavalink.WithPaused(p.paused),
+		lavalink.WithFilters(p.filters),
+	}
+	if p.track != nil {
+		opts = append(opts, lavalink.WithEncodedTrack(p.track.Encoded), lavalink.WithPosition(p.position()))
+		if len(p.track.UserData) > 0 {
+			opts = append(opts, lavalink.WithTrackUserData(p.track.UserData))
+		}
+	}
+	if p.voice.Token != "" && p.voice.Endpoint != "" && p.voice.SessionID != "" {
+		opts = append(opts, lavalink.WithVoice(p.voice))
+	}
+
+	update := lavalink.DefaultPlayerUpdate()
+	update.Apply(opts)
+	return *update
+}
+
 func (p *playerImpl) Node() Node {
+	if node := p.currentNode(); node != nil {
+		return node
+	}
+
+	node := p.lavalink.BestNode()
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.node == nil {
-		p.node = p.lavalink.BestNode()
+		p.node = node
 	}
 	return p.node
 }
@@ -182,6 +452,8 @@ func (p *playerImpl) Lavalink() Client {
 }
 
 func (p *playerImpl) Restore(player lavalink.Player) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.track = player.Track
 	p.state = player.State
 	p.paused = player.Paused
@@ -191,8 +463,7 @@ func (p *playerImpl) Restore(player lavalink.Player) {
 }
 
 func (p *playerImpl) OnEvent(event lavalink.Event) {
-	switch e := event.(type) {
-	case lavalink.UnknownEvent:
+	if e, ok := event.(lavalink.UnknownEvent); ok {
 		p.lavalink.ForPlugins(func(plugin Plugin) {
 			if pl, ok := plugin.(EventPlugin); ok && pl.Event() == e.Type() {
 				pl.OnEventInvocation(p, e.Data)
@@ -205,6 +476,12 @@ func (p *playerImpl) OnEvent(event lavalink.Event) {
 				}
 			}
 		})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e := event.(type) {
 	case lavalink.PlayerPauseEvent:
 		p.paused = true
 
@@ -212,23 +489,11 @@ func (p *playerImpl) OnEvent(event lavalink.Event) {
 		p.paused = false
 
 	case lavalink.TrackEndEvent:
-		if p.track != nil {
-			e.Track = *p.track
-		}
 		if e.Reason != lavalink.TrackEndReasonReplaced && e.Reason != lavalink.TrackEndReasonStopped {
 			p.track = nil
 		}
 
-	case lavalink.TrackExceptionEvent:
-		if p.track != nil {
-			e.Track = *p.track
-		}
-		p.track = nil
-
-	case lavalink.TrackStuckEvent:
-		if p.track != nil {
-			e.Track = *p.track
-		}
+	case lavalink.TrackExceptionEvent, lavalink.TrackStuckEvent:
 		p.track = nil
 
 	case lavalink.WebSocketClosedEvent:
@@ -238,32 +503,59 @@ func (p *playerImpl) OnEvent(event lavalink.Event) {
 }
 
 func (p *playerImpl) OnPlayerUpdate(state lavalink.PlayerState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.state = state
 }
 
 func (p *playerImpl) OnVoiceServerUpdate(ctx context.Context, token string, endpoint string) {
-	if _, err := p.Node().Rest().UpdatePlayer(ctx, p.node.SessionID(), p.guildID, lavalink.PlayerUpdate{
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "error while waiting to send voice server update", slog.Any("err", err))
+		return
+	}
+	defer release()
+
+	node := p.Node()
+	p.mu.RLock()
+	sessionID := p.voice.SessionID
+	p.mu.RUnlock()
+
+	if _, err := node.Rest().UpdatePlayer(ctx, node.SessionID(), p.guildID, lavalink.PlayerUpdate{
 		Voice: &lavalink.VoiceState{
 			Token:     token,
 			Endpoint:  endpoint,
-			SessionID: p.voice.SessionID,
+			SessionID: sessionID,
 		},
 	}); err != nil {
 		p.logger.ErrorContext(ctx, "error while sending voice server update", slog.Any("err", err))
 	}
+	p.mu.Lock()
 	p.voice.Token = token
 	p.voice.Endpoint = endpoint
+	p.mu.Unlock()
 }
 
 func (p *playerImpl) OnVoiceStateUpdate(ctx context.Context, channelID *snowflake.ID, sessionID string) {
 	if channelID == nil {
+		p.mu.Lock()
 		p.channelID = nil
+		p.mu.Unlock()
 		if err := p.Destroy(ctx); err != nil {
 			p.logger.ErrorContext(ctx, "error while destroying player", slog.Any("err", err))
 		}
 		p.lavalink.RemovePlayer(p.guildID)
 		return
 	}
+	release, err := p.queue.acquire(ctx, nil)
+	if err != nil {
+		p.logger.ErrorContext(ctx, "error while waiting to apply voice state update", slog.Any("err", err))
+		return
+	}
+	defer release()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	p.channelID = channelID
 	p.voice.SessionID = sessionID
 }
@@ -0,0 +1,21 @@
+package disgolink
+
+import (
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+const (
+	EventTypePlayerMove lavalink.EventType = "PlayerMoveEvent" // not actually sent by lavalink
+)
+
+// PlayerMoveEvent is emitted when a Player has been moved from one Node to another.
+type PlayerMoveEvent struct {
+	GuildID_ snowflake.ID
+	From     Node
+	To       Node
+}
+
+func (PlayerMoveEvent) Op() lavalink.Op          { return lavalink.OpEvent }
+func (PlayerMoveEvent) Type() lavalink.EventType { return EventTypePlayerMove }
+func (e PlayerMoveEvent) GuildID() snowflake.ID  { return e.GuildID_ }
@@ -0,0 +1,104 @@
+package disgolink
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+var errSuperseded = errors.New("superseded by a newer update")
+
+type mergeKey struct{}
+
+// WithMerge returns a context which lets a Player.Update be merged with a queued update of the same kind which has not been sent yet.
+// Only the newest update is sent and all merged calls return its result. This is useful for rapid updates like a volume slider.
+func WithMerge(ctx context.Context) context.Context {
+	return context.WithValue(ctx, mergeKey{}, true)
+}
+
+func mergeEnabled(ctx context.Context) bool {
+	merge, _ := ctx.Value(mergeKey{}).(bool)
+	return merge
+}
+
+// opQueue runs operations one at a time in call order. Each caller waits for the operation queued before it to finish.
+type opQueue struct {
+	mu   sync.Mutex
+	tail chan struct{}
+}
+
+// acquire waits until all previously queued operations have finished. The returned func must be called once the operation is done.
+// If the context is done or abort is closed before it is the callers turn, the error is returned and the queue continues without the caller.
+func (q *opQueue) acquire(ctx context.Context, abort <-chan struct{}) (func(), error) {
+	done := make(chan struct{})
+	q.mu.Lock()
+	prev := q.tail
+	q.tail = done
+	q.mu.Unlock()
+
+	release := func() { close(done) }
+	if prev == nil {
+		return release, nil
+	}
+
+	var err error
+	select {
+	case <-prev:
+		return release, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-abort:
+		err = errSuperseded
+	}
+	// hand our turn over to the next operation once the previous one is done
+	go func() {
+		<-prev
+		release()
+	}()
+	return nil, err
+}
+
+// updateKind is a bitmask of the fields set in a lavalink.PlayerUpdate. Only updates of the same kind are merged.
+type updateKind uint8
+
+func updateKindOf(update lavalink.PlayerUpdate) updateKind {
+	var kind updateKind
+	for i, set := range []bool{
+		update.Track != nil,
+		update.Position != nil,
+		update.EndTime != nil,
+		update.Volume != nil,
+		update.Paused != nil,
+		update.Voice != nil,
+		update.Filters != nil,
+		update.NoReplace,
+	} {
+		if set {
+			kind |= 1 << i
+		}
+	}
+	return kind
+}
+
+// queuedUpdate is a mergeable update waiting in the opQueue.
+type queuedUpdate struct {
+	// next is the update which superseded this one, it is set before superseded is closed.
+	next       *queuedUpdate
+	superseded chan struct{}
+	done       chan struct{}
+	err        error
+}
+
+func newQueuedUpdate() *queuedUpdate {
+	return &queuedUpdate{
+		superseded: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func (u *queuedUpdate) finish(err error) {
+	u.err = err
+	close(u.done)
+}
@@ -0,0 +1,260 @@
+package disgolink
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlayer_Concurrent drives updates, events and voice callbacks concurrently and is meant to be run with -race.
+func TestPlayer_Concurrent(t *testing.T) {
+	node := newTestNode(t, testPlayerJSON)
+	node.status = StatusConnected
+	node.statsHistory = newStatsHistory(10)
+	client := node.lavalink.(*clientImpl)
+	client.nodes[node.config.Name] = node
+
+	guildID := snowflake.ID(817327181659111454)
+	player := client.PlayerOnNode(node, guildID)
+	ctx := context.Background()
+	channelID := snowflake.ID(1)
+
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	run(func(i int) {
+		assert.NoError(t, player.Update(ctx, lavalink.WithPaused(i%2 == 0)))
+	})
+	run(func(i int) {
+		assert.NoError(t, player.Recreate(ctx))
+	})
+	run(func(i int) {
+		player.OnEvent(lavalink.TrackEndEvent{GuildID_: guildID, Reason: lavalink.TrackEndReasonFinished})
+		player.OnEvent(lavalink.PlayerPauseEvent{GuildID_: guildID})
+		player.OnEvent(lavalink.WebSocketClosedEvent{GuildID_: guildID})
+	})
+	run(func(i int) {
+		player.OnPlayerUpdate(lavalink.PlayerState{Position: lavalink.Duration(i), Connected: true})
+	})
+	run(func(i int) {
+		player.OnVoiceServerUpdate(ctx, "token", "endpoint")
+		player.OnVoiceStateUpdate(ctx, &channelID, "session")
+	})
+	run(func(i int) {
+		_ = player.Track()
+		_ = player.Paused()
+		_ = player.Position()
+		_ = player.State()
+		_ = player.Volume()
+		_ = player.Filters()
+		_ = player.ChannelID()
+		_ = player.Node()
+		_ = traceContext(player)
+	})
+	run(func(i int) {
+		node.recordStats(lavalink.Stats{Players: i})
+		node.setStatus(StatusConnected)
+		assert.NoError(t, node.Update(ctx, lavalink.SessionUpdate{Resuming: json.Ptr(true)}))
+	})
+	run(func(i int) {
+		_ = node.Stats()
+		_ = node.Status()
+		_ = node.SessionID()
+		_ = node.Config()
+		_ = client.BestNode()
+	})
+	wg.Wait()
+
+	assert.Equal(t, Node(node), player.Node())
+	assert.Equal(t, &channelID, player.ChannelID())
+}
+
+// waitQueued waits until an operation queued after tail is waiting in the queue.
+func waitQueued(q *opQueue, tail chan struct{}) {
+	for {
+		q.mu.Lock()
+		queued := q.tail != tail
+		q.mu.Unlock()
+		if queued {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestOpQueue(t *testing.T) {
+	var q opQueue
+	release, err := q.acquire(context.Background(), nil)
+	assert.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 5; i++ {
+		ctx := context.Background()
+		if i == 2 {
+			ctx = cancelCtx
+		}
+		q.mu.Lock()
+		tail := q.tail
+		q.mu.Unlock()
+
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			release, err := q.acquire(ctx, nil)
+			if err != nil {
+				assert.ErrorIs(t, err, context.Canceled)
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			release()
+		}(i, ctx)
+		waitQueued(&q, tail)
+	}
+
+	cancel()
+	release()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 3, 4}, order)
+}
+
+func TestPlayer_MergeUpdates(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		volumes []int
+	)
+	node := newTestNodeWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		var update lavalink.PlayerUpdate
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&update))
+		mu.Lock()
+		volumes = append(volumes, *update.Volume)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testPlayerJSON))
+	})
+	player := NewPlayer(node.logger, node.lavalink, node, snowflake.ID(817327181659111454)).(*playerImpl)
+
+	// hold the queue, so the updates below have to wait
+	release, err := player.queue.acquire(context.Background(), nil)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for volume := 1; volume <= 5; volume++ {
+		ctx := context.Background()
+		if volume != 3 {
+			ctx = WithMerge(ctx)
+		}
+		player.queue.mu.Lock()
+		tail := player.queue.tail
+		player.queue.mu.Unlock()
+
+		wg.Add(1)
+		go func(ctx context.Context, volume int) {
+			defer wg.Done()
+			assert.NoError(t, player.Update(ctx, lavalink.WithVolume(volume)))
+		}(ctx, volume)
+		waitQueued(&player.queue, tail)
+	}
+
+	release()
+	wg.Wait()
+	// 3 is not mergeable and keeps its place, all others are superseded by 5 which is sent last
+	assert.Equal(t, []int{3, 5}, volumes)
+}
+
+// newTestMoveNode returns a connected node which records the methods of its requests and answers player updates with status.
+func newTestMoveNode(t *testing.T, name string, status int, mu *sync.Mutex, requests *[]string) *nodeImpl {
+	node := newTestNodeWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		*requests = append(*requests, name+" "+r.Method+" "+string(body))
+		mu.Unlock()
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testPlayerJSON))
+	})
+	node.config.Name = name
+	node.status = StatusConnected
+	return node
+}
+
+func TestPlayer_MoveTo(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	client := newTestClient()
+	source := newTestMoveNode(t, "source", http.StatusOK, &mu, &requests)
+	target := newTestMoveNode(t, "target", http.StatusOK, &mu, &requests)
+
+	var state lavalink.Player
+	require.NoError(t, json.Unmarshal([]byte(testPlayerJSON), &state))
+	player := client.PlayerOnNode(source, state.GuildID)
+	player.Restore(state)
+
+	require.NoError(t, player.MoveTo(context.Background(), target))
+	assert.Equal(t, Node(target), player.Node())
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, requests, 2) {
+		// the player is created on the target before it is destroyed on the source
+		assert.True(t, strings.HasPrefix(requests[0], "target PATCH "), requests[0])
+		assert.Contains(t, requests[0], `"encoded":"`+state.Track.Encoded+`"`)
+		assert.Contains(t, requests[0], `"volume":100`)
+		assert.Contains(t, requests[0], `"endpoint":"rotterdam1234.discord.media"`)
+		assert.Equal(t, "source DELETE ", requests[1])
+	}
+}
+
+func TestPlayer_MoveToRollback(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	client := newTestClient()
+	source := newTestMoveNode(t, "source", http.StatusOK, &mu, &requests)
+	target := newTestMoveNode(t, "target", http.StatusInternalServerError, &mu, &requests)
+
+	player := client.PlayerOnNode(source, 1)
+	assert.ErrorIs(t, player.MoveTo(context.Background(), target), ErrServerError)
+	assert.Equal(t, Node(source), player.Node(), "the player should stay on the source node")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, requests, 1) {
+		assert.True(t, strings.HasPrefix(requests[0], "target PATCH "), requests[0])
+	}
+}
@@ -28,3 +28,8 @@ type PluginEventHandler interface {
 	OnNewPlayer(player Player)
 	OnDestroyPlayer(player Player)
 }
+
+// PluginMoveHandler is an optional interface for plugins which want to know when a Player is moved to another Node. See Player.MoveTo.
+type PluginMoveHandler interface {
+	OnMovePlayer(player Player, from Node, to Node)
+}
@@ -0,0 +1,170 @@
+package disgolink
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// RebalanceConfig configures how players are moved from overloaded to healthy nodes. See WithRebalancer.
+type RebalanceConfig struct {
+	// Interval is how often the nodes are checked. Defaults to 1 minute.
+	Interval time.Duration
+	// MaxMoves is the maximum number of players moved per interval. Defaults to 5.
+	MaxMoves int
+	// Cooldown is how long a moved player is not moved again. Defaults to 10 minutes.
+	Cooldown time.Duration
+	// Overloaded are the thresholds above which players are moved away from a node. Defaults to a cpu load of 0.9 or a frame deficit ratio of 0.05.
+	Overloaded HealthThresholds
+	// Healthy are the thresholds a node has to be within to receive players. They should be lower than Overloaded, so players are not moved back and forth.
+	// Defaults to a cpu load of 0.6 and a frame deficit ratio of 0.01.
+	Healthy HealthThresholds
+}
+
+func (c RebalanceConfig) withDefaults() RebalanceConfig {
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	if c.MaxMoves <= 0 {
+		c.MaxMoves = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 10 * time.Minute
+	}
+	if !c.Overloaded.Enabled() {
+		c.Overloaded = HealthThresholds{MaxCPULoad: 0.9, MaxFrameDeficitRatio: 0.05}
+	}
+	if !c.Healthy.Enabled() {
+		c.Healthy = HealthThresholds{MaxCPULoad: 0.6, MaxFrameDeficitRatio: 0.01}
+	}
+	return c
+}
+
+func newRebalancer(client *clientImpl, config RebalanceConfig) *rebalancer {
+	return &rebalancer{
+		client: client,
+		config: config.withDefaults(),
+		moved:  map[snowflake.ID]time.Time{},
+	}
+}
+
+// rebalancer periodically moves players from nodes exceeding RebalanceConfig.Overloaded to nodes within RebalanceConfig.Healthy.
+type rebalancer struct {
+	client *clientImpl
+	config RebalanceConfig
+
+	mu    sync.Mutex
+	moved map[snowflake.ID]time.Time
+}
+
+func (r *rebalancer) run(ctx context.Context) {
+	defer r.client.logger.Debug("exiting rebalancer goroutine")
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.rebalance(ctx)
+		}
+	}
+}
+
+// rebalance moves up to RebalanceConfig.MaxMoves players and returns how many were moved.
+func (r *rebalancer) rebalance(ctx context.Context) int {
+	var (
+		overloaded = map[Node]struct{}{}
+		healthy    []Node
+	)
+	r.client.nodesMu.Lock()
+	for name, node := range r.client.nodes {
+		if _, ok := r.client.drainingNodes[name]; ok || node.Status() != StatusConnected {
+			continue
+		}
+		history := node.StatsHistory()
+		if history == nil {
+			continue
+		}
+		summary := history.Summary()
+		if summary.Samples == 0 {
+			continue
+		}
+		if len(r.config.Overloaded.Check(summary)) > 0 {
+			overloaded[node] = struct{}{}
+		} else if len(r.config.Healthy.Check(summary)) == 0 {
+			healthy = append(healthy, node)
+		}
+	}
+	r.client.nodesMu.Unlock()
+
+	if len(overloaded) == 0 || len(healthy) == 0 {
+		return 0
+	}
+	sort.Slice(healthy, func(i, j int) bool {
+		return healthy[i].Config().Name < healthy[j].Config().Name
+	})
+
+	now := time.Now()
+	var players []Player
+	r.mu.Lock()
+	for guildID, movedAt := range r.moved {
+		if now.Sub(movedAt) > r.config.Cooldown {
+			delete(r.moved, guildID)
+		}
+	}
+	r.client.ForPlayers(func(player Player) {
+		if _, ok := overloaded[player.Node()]; !ok || r.client.Pinned(player.GuildID()) {
+			return
+		}
+		if _, ok := r.moved[player.GuildID()]; ok {
+			return
+		}
+		players = append(players, player)
+	})
+	r.mu.Unlock()
+
+	sort.Slice(players, func(i, j int) bool {
+		return players[i].GuildID() < players[j].GuildID()
+	})
+
+	var moves int
+	for _, player := range players {
+		if moves >= r.config.MaxMoves || ctx.Err() != nil {
+			break
+		}
+		from := player.Node()
+		targets := healthy
+		if regions := from.Config().Regions; len(regions) > 0 {
+			// keep players in the region of their voice server
+			targets = slices.DeleteFunc(slices.Clone(healthy), func(node Node) bool {
+				return !slices.ContainsFunc(regions, node.Config().HasRegion)
+			})
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		to := r.client.loadBalancer.SelectNode(targets, player.GuildID())
+		if to == nil {
+			continue
+		}
+
+		if err := player.MoveTo(ctx, to); err != nil {
+			r.client.logger.ErrorContext(ctx, "failed to rebalance player", slog.Any("err", err), slog.Int64("guild_id", int64(player.GuildID())), slog.String("from", from.Config().Name), slog.String("to", to.Config().Name))
+			continue
+		}
+		r.client.logger.DebugContext(ctx, "rebalanced player", slog.Int64("guild_id", int64(player.GuildID())), slog.String("from", from.Config().Name), slog.String("to", to.Config().Name))
+		r.mu.Lock()
+		r.moved[player.GuildID()] = time.Now()
+		r.mu.Unlock()
+		moves++
+	}
+	return moves
+}
@@ -0,0 +1,47 @@
+package disgolink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebalancer(t *testing.T) {
+	overloadedNode := newTestNode(t, testPlayerJSON)
+	client := overloadedNode.lavalink.(*clientImpl)
+	healthyNode := newTestNode(t, testPlayerJSON)
+	healthyNode.lavalink = client
+	healthyNode.config.Name = "healthy"
+
+	for _, node := range []*nodeImpl{overloadedNode, healthyNode} {
+		node.status = StatusConnected
+		node.statsHistory = newStatsHistory(1)
+		client.nodes[node.config.Name] = node
+	}
+	overloadedNode.statsHistory.add(StatsSample{Stats: testStats(0.95, 10, 0)})
+	healthyNode.statsHistory.add(StatsSample{Stats: testStats(0.1, 10, 0)})
+
+	for guildID := snowflake.ID(1); guildID <= 4; guildID++ {
+		client.PlayerOnNode(overloadedNode, guildID)
+	}
+	client.PinGuild(1)
+
+	r := newRebalancer(client, RebalanceConfig{MaxMoves: 2})
+	assert.Equal(t, 2, r.rebalance(context.Background()))
+	assert.Equal(t, Node(overloadedNode), client.ExistingPlayer(1).Node())
+	assert.Equal(t, Node(healthyNode), client.ExistingPlayer(2).Node())
+	assert.Equal(t, Node(healthyNode), client.ExistingPlayer(3).Node())
+	assert.Equal(t, Node(overloadedNode), client.ExistingPlayer(4).Node())
+
+	// moved players are not moved back while cooling down even if the nodes swap roles
+	overloadedNode.statsHistory.add(StatsSample{Stats: testStats(0.1, 10, 0)})
+	healthyNode.statsHistory.add(StatsSample{Stats: testStats(0.95, 10, 0)})
+	assert.Equal(t, 0, r.rebalance(context.Background()))
+
+	// a node between both thresholds neither sends nor receives players
+	healthyNode.statsHistory.add(StatsSample{Stats: testStats(0.7, 10, 0)})
+	overloadedNode.statsHistory.add(StatsSample{Stats: testStats(0.95, 10, 0)})
+	assert.Equal(t, 0, r.rebalance(context.Background()))
+}
@@ -0,0 +1,61 @@
+package disgolink
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy decides whether and when a Node tries to connect again after a failed attempt or a lost connection.
+type ReconnectPolicy interface {
+	// NextDelay returns how long to wait before the given attempt, starting at 1. elapsed is the time since the first attempt.
+	// If false is returned the node gives up.
+	NextDelay(attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// DefaultReconnectPolicy returns an ExponentialBackoff which retries forever with a delay between 1 and 30 seconds.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return &ExponentialBackoff{
+		MinDelay:   time.Second,
+		MaxDelay:   30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+var _ ReconnectPolicy = (*ExponentialBackoff)(nil)
+
+// ExponentialBackoff is a ReconnectPolicy which multiplies the delay with each attempt.
+type ExponentialBackoff struct {
+	// MinDelay is the delay before the first attempt.
+	MinDelay time.Duration
+	// MaxDelay caps the delay between attempts.
+	MaxDelay time.Duration
+	// Multiplier is applied to the delay after each attempt. Values below 1 are treated as 1.
+	Multiplier float64
+	// Jitter randomizes each delay by up to the given fraction, for example 0.2 for ±20%.
+	Jitter float64
+	// MaxAttempts is the maximum number of attempts. 0 means unlimited.
+	MaxAttempts int
+	// MaxElapsed is the maximum time spent on attempts. 0 means unlimited.
+	MaxElapsed time.Duration
+}
+
+func (b *ExponentialBackoff) NextDelay(attempt int, elapsed time.Duration) (time.Duration, bool) {
+	if b.MaxAttempts > 0 && attempt > b.MaxAttempts {
+		return 0, false
+	}
+	if b.MaxElapsed > 0 && elapsed >= b.MaxElapsed {
+		return 0, false
+	}
+
+	multiplier := math.Max(b.Multiplier, 1)
+	delay := float64(b.MinDelay) * math.Pow(multiplier, float64(attempt-1))
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(delay), true
+}
@@ -0,0 +1,45 @@
+package disgolink
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff_NextDelay(t *testing.T) {
+	policy := &ExponentialBackoff{
+		MinDelay:    time.Second,
+		MaxDelay:    5 * time.Second,
+		Multiplier:  2,
+		MaxAttempts: 4,
+		MaxElapsed:  time.Minute,
+	}
+
+	var delays []time.Duration
+	for attempt := 1; ; attempt++ {
+		delay, ok := policy.NextDelay(attempt, 0)
+		if !ok {
+			break
+		}
+		delays = append(delays, delay)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}, delays)
+
+	_, ok := policy.NextDelay(1, time.Minute)
+	assert.False(t, ok)
+}
+
+func TestExponentialBackoff_NextDelayJitter(t *testing.T) {
+	policy := &ExponentialBackoff{
+		MinDelay: time.Second,
+		Jitter:   0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay, ok := policy.NextDelay(1, 0)
+		assert.True(t, ok)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1500*time.Millisecond)
+	}
+}
@@ -0,0 +1,50 @@
+package disgolink
+
+import (
+	"net"
+	"strings"
+)
+
+// DefaultRegions maps regions to the names Discord uses in its voice server endpoints.
+var DefaultRegions = map[string][]string{
+	"eu":   {"rotterdam", "amsterdam", "frankfurt", "europe", "london", "madrid", "milan", "stockholm", "bucharest", "russia"},
+	"us":   {"us-east", "us-west", "us-central", "us-south", "atlanta", "newark", "seattle", "santa-clara", "oregon", "brazil"},
+	"asia": {"singapore", "hongkong", "japan", "india", "south-korea", "dubai", "sydney"},
+}
+
+// RegionResolver maps a Discord voice server endpoint like rotterdam1234.discord.media to a region.
+type RegionResolver interface {
+	// Region returns the region of the endpoint or an empty string if the region is unknown.
+	Region(endpoint string) string
+}
+
+// RegionResolverFunc is a function implementing RegionResolver.
+type RegionResolverFunc func(endpoint string) string
+
+func (f RegionResolverFunc) Region(endpoint string) string {
+	return f(endpoint)
+}
+
+// NewRegionResolver returns a RegionResolver which matches the voice server name of an endpoint against the given names of each region.
+// If regions is nil DefaultRegions is used.
+func NewRegionResolver(regions map[string][]string) RegionResolver {
+	if regions == nil {
+		regions = DefaultRegions
+	}
+	return RegionResolverFunc(func(endpoint string) string {
+		server := endpoint
+		if host, _, err := net.SplitHostPort(endpoint); err == nil {
+			server = host
+		}
+		server, _, _ = strings.Cut(strings.ToLower(server), ".")
+
+		for region, names := range regions {
+			for _, name := range names {
+				if strings.Contains(server, name) {
+					return region
+				}
+			}
+		}
+		return ""
+	})
+}
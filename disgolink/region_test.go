@@ -0,0 +1,43 @@
+package disgolink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionResolver_Region(t *testing.T) {
+	resolver := NewRegionResolver(nil)
+
+	assert.Equal(t, "eu", resolver.Region("rotterdam1234.discord.media"))
+	assert.Equal(t, "eu", resolver.Region("rotterdam1234.discord.media:443"))
+	assert.Equal(t, "us", resolver.Region("us-east42.discord.media"))
+	assert.Equal(t, "", resolver.Region("unknown1.discord.media"))
+}
+
+func TestClient_OnVoiceUpdatesRegion(t *testing.T) {
+	var moves int
+	client := newTestClient(WithRegionResolver(NewRegionResolver(nil)), WithListenerFunc(func(_ Player, _ PlayerMoveEvent) {
+		moves++
+	}))
+	for _, region := range []string{"eu", "us"} {
+		node := newTestNode(t, testPlayerJSON)
+		node.config.Name = region
+		node.config.Regions = []string{region}
+		node.status = StatusConnected
+		client.nodes[region] = node
+	}
+
+	channelID := snowflake.ID(2)
+	client.OnVoiceStateUpdate(context.Background(), 1, &channelID, "session")
+	player := client.ExistingPlayer(1)
+	assert.Nil(t, playerNode(player), "the node should not be selected before the region is known")
+
+	client.OnVoiceServerUpdate(context.Background(), 1, "token", "us-east42.discord.media")
+	if assert.NotNil(t, playerNode(player)) {
+		assert.Equal(t, "us", playerNode(player).Config().Name)
+	}
+	assert.Zero(t, moves)
+}
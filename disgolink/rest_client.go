@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 
 	"github.com/disgoorg/disgolink/v3/lavalink"
 	"github.com/disgoorg/snowflake/v2"
@@ -36,6 +39,10 @@ var (
 	EndpointDecodeTrack  = EndpointBase + "/decodetrack?track=%s"
 	EndpointDecodeTracks = EndpointBase + "/decodetracks"
 
+	EndpointRoutePlannerStatus      = EndpointBase + "/routeplanner/status"
+	EndpointRoutePlannerFreeAddress = EndpointBase + "/routeplanner/free/address"
+	EndpointRoutePlannerFreeAll     = EndpointBase + "/routeplanner/free/all"
+
 	EndpointWebSocket = EndpointBase + "/websocket"
 )
 
@@ -57,20 +64,51 @@ type RestClient interface {
 	LoadTracks(ctx context.Context, identifier string) (*lavalink.LoadResult, error)
 	DecodeTrack(ctx context.Context, encodedTrack string) (*lavalink.Track, error)
 	DecodeTracks(ctx context.Context, encodedTracks []string) ([]lavalink.Track, error)
+
+	// RoutePlannerStatus returns the status of the route planner or nil if no route planner is configured.
+	RoutePlannerStatus(ctx context.Context) (*lavalink.RoutePlannerStatus, error)
+	FreeRoutePlannerAddress(ctx context.Context, address string) error
+	FreeAllRoutePlannerAddresses(ctx context.Context) error
+}
+
+// RestHandler sends a rest request to a Node. Responses with a status code of 400 or above are returned together with a *RestError
+// and their body can still be read.
+type RestHandler func(rq *http.Request) (*http.Response, error)
+
+var errNoResponse = errors.New("rest handler returned neither a response nor an error")
+
+// RestMiddleware wraps a RestHandler to inspect or modify requests, responses and errors. It can also answer requests itself without calling next.
+type RestMiddleware func(next RestHandler) RestHandler
+
+func newRestClient(logger *slog.Logger, node Node, httpClient *http.Client, retry RestRetryConfig, middlewares []RestMiddleware) *restClientImpl {
+	c := &restClientImpl{
+		logger:     logger,
+		node:       node,
+		httpClient: httpClient,
+		retry:      retry,
+	}
+	c.handler = c.send
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		c.handler = middlewares[i](c.handler)
+	}
+	return c
 }
 
 type restClientImpl struct {
 	logger     *slog.Logger
 	node       Node
 	httpClient *http.Client
+	retry      RestRetryConfig
+	handler    RestHandler
 }
 
 func (c *restClientImpl) Version(ctx context.Context) (string, error) {
-	_, rawBody, err := c.do(ctx, http.MethodGet, string(EndpointVersion), nil)
-	if err != nil {
-		return "", err
-	}
-	return string(rawBody), nil
+	var version string
+	err := c.do(ctx, http.MethodGet, string(EndpointVersion), nil, func(body []byte) error {
+		version = string(body)
+		return nil
+	})
+	return version, err
 }
 
 func (c *restClientImpl) Info(ctx context.Context) (info *lavalink.Info, err error) {
@@ -104,8 +142,7 @@ func (c *restClientImpl) UpdatePlayer(ctx context.Context, sessionID string, gui
 }
 
 func (c *restClientImpl) DestroyPlayer(ctx context.Context, sessionID string, guildID snowflake.ID) error {
-	_, _, err := c.do(ctx, http.MethodDelete, EndpointDestroyPlayer.Format(sessionID, guildID), nil)
-	return err
+	return c.do(ctx, http.MethodDelete, EndpointDestroyPlayer.Format(sessionID, guildID), nil, nil)
 }
 
 func (c *restClientImpl) LoadTracks(ctx context.Context, identifier string) (result *lavalink.LoadResult, err error) {
@@ -123,6 +160,20 @@ func (c *restClientImpl) DecodeTracks(ctx context.Context, encodedTracks []strin
 	return
 }
 
+func (c *restClientImpl) RoutePlannerStatus(ctx context.Context) (status *lavalink.RoutePlannerStatus, err error) {
+	// lavalink responds with no content if no route planner is configured, which leaves status nil
+	err = c.doJSON(ctx, http.MethodGet, string(EndpointRoutePlannerStatus), nil, &status)
+	return
+}
+
+func (c *restClientImpl) FreeRoutePlannerAddress(ctx context.Context, address string) error {
+	return c.doJSON(ctx, http.MethodPost, string(EndpointRoutePlannerFreeAddress), lavalink.RoutePlannerFreeAddress{Address: address}, nil)
+}
+
+func (c *restClientImpl) FreeAllRoutePlannerAddresses(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, string(EndpointRoutePlannerFreeAll), nil, nil)
+}
+
 func (c *restClientImpl) Do(rq *http.Request) (*http.Response, error) {
 	rq.Header.Set("Authorization", c.node.Config().Password)
 	rq.URL.Host = c.node.Config().Address
@@ -131,61 +182,187 @@ func (c *restClientImpl) Do(rq *http.Request) (*http.Response, error) {
 	} else {
 		rq.URL.Scheme = "http"
 	}
-	return c.httpClient.Do(rq)
+	rs, err := c.handler(rq)
+	// Do returns responses as they are, without turning them into a RestError. A middleware might return a RestError without a response though.
+	var restErr *RestError
+	if rs != nil && errors.As(err, &restErr) {
+		return rs, nil
+	}
+	if rs == nil && err == nil {
+		return nil, errNoResponse
+	}
+	return rs, err
+}
+
+// send is the last RestHandler of the middleware chain. It decodes responses with a status code of 400 or above into a RestError.
+func (c *restClientImpl) send(rq *http.Request) (*http.Response, error) {
+	rs, err := c.httpClient.Do(rq)
+	if err != nil || rs.StatusCode < http.StatusBadRequest {
+		return rs, err
+	}
+
+	defer rs.Body.Close()
+	rawBody, err := io.ReadAll(rs.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	rs.Body = io.NopCloser(bytes.NewReader(rawBody))
+	return rs, newRestError(rq.Method, rq.URL.Path, rs.StatusCode, rawBody)
 }
 
-func (c *restClientImpl) do(ctx context.Context, method string, path string, rqBody []byte) (int, []byte, error) {
+// do sends the request and retries it according to the RestRetryConfig. handleBody is called with the body of successful responses
+// which have content. The body is only valid during the call.
+func (c *restClientImpl) do(ctx context.Context, method string, path string, rqBody []byte, handleBody func(body []byte) error) error {
+	endpoint := RestEndpoint(path)
+	ctx, span := c.node.Lavalink().Tracer().Start(ctx, method+" "+endpoint,
+		slog.String(AttrNodeName, c.node.Config().Name),
+		slog.String(AttrMethod, method),
+		slog.String(AttrEndpoint, endpoint),
+	)
+	defer span.End()
+
+	retryable := c.retry.MaxRetries > 0 && c.retry.retryable(method, path)
+	for attempt := 1; ; attempt++ {
+		rs, err := c.doOnce(ctx, method, path, rqBody, handleBody)
+		var statusCode int
+		if rs != nil {
+			statusCode = rs.StatusCode
+		}
+		var (
+			delay time.Duration
+			retry = retryable && attempt <= c.retry.MaxRetries && ctx.Err() == nil && shouldRetry(statusCode, err)
+		)
+		if retry {
+			delay, retry = c.retry.backoff(attempt, rs)
+		}
+		if !retry {
+			span.SetAttributes(slog.Int(AttrStatusCode, statusCode), slog.Int(AttrAttempts, attempt))
+			if err != nil {
+				span.RecordError(err)
+			}
+			return err
+		}
+
+		c.logger.WarnContext(ctx, "retrying request", slog.String("method", method), slog.String("path", path), slog.Int("attempt", attempt), slog.Duration("delay", delay), slog.Int("status_code", statusCode), slog.Any("err", err))
+		c.node.Lavalink().EmitEvent(nil, RestRetryEvent{
+			Node_:      c.node,
+			Method:     method,
+			Path:       path,
+			Attempt:    attempt,
+			Delay:      delay,
+			StatusCode: statusCode,
+			Err:        err,
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			span.SetAttributes(slog.Int(AttrStatusCode, statusCode), slog.Int(AttrAttempts, attempt))
+			if err != nil {
+				span.RecordError(err)
+			}
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *restClientImpl) doOnce(ctx context.Context, method string, path string, rqBody []byte, handleBody func(body []byte) error) (*http.Response, error) {
+	if c.retry.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.retry.AttemptTimeout)
+		defer cancel()
+	}
+
 	rq, err := http.NewRequestWithContext(ctx, method, c.node.Config().RestURL()+path, bytes.NewReader(rqBody))
 	if err != nil {
-		return 0, nil, err
+		return nil, err
+	}
+	if c.node.Config().Trace || traceEnabled(ctx) {
+		query := rq.URL.Query()
+		query.Set("trace", "true")
+		rq.URL.RawQuery = query.Encode()
 	}
 	rq.Header.Set("Authorization", c.node.Config().Password)
 	if len(rqBody) > 0 {
 		rq.Header.Set("Content-Type", "application/json")
 	}
 
-	c.logger.DebugContext(ctx, "sending request", slog.String("method", method), slog.String("path", path), slog.String("body", fmt.Sprintf("%v", rqBody)))
-
-	rs, err := c.httpClient.Do(rq)
-	if err != nil {
-		return 0, nil, err
+	debug := c.logger.Enabled(ctx, slog.LevelDebug)
+	if debug {
+		c.logger.DebugContext(ctx, "sending request", slog.String("method", method), slog.String("path", path), slog.String("body", string(rqBody)))
 	}
 
-	defer rs.Body.Close()
-	rawBody, err := io.ReadAll(rs.Body)
-	c.logger.DebugContext(ctx, "received response", slog.String("path", path), slog.Int("status_code", rs.StatusCode), slog.String("body", string(rawBody)))
-	if err != nil {
-		return rs.StatusCode, nil, fmt.Errorf("failed to read response body: %w", err)
+	rs, err := c.handler(rq)
+	if rs == nil {
+		if err == nil {
+			err = errNoResponse
+		}
+		return nil, err
 	}
+	defer rs.Body.Close()
 
-	if rs.StatusCode >= http.StatusBadRequest {
-		var lavalinkErr lavalink.Error
-		if err = json.Unmarshal(rawBody, &lavalinkErr); err != nil {
-			return rs.StatusCode, rawBody, fmt.Errorf("error while unmarshalling disgolink error: %w", err)
-		}
-		return rs.StatusCode, nil, lavalinkErr
+	buf := getBuffer()
+	defer putBuffer(buf)
+	_, readErr := buf.ReadFrom(rs.Body)
+	if debug {
+		c.logger.DebugContext(ctx, "received response", slog.String("path", path), slog.Int("status_code", rs.StatusCode), slog.String("body", buf.String()))
+	}
+	if readErr != nil {
+		return rs, fmt.Errorf("failed to read response body: %w", readErr)
 	}
 
-	return rs.StatusCode, rawBody, nil
+	if err == nil && rs.StatusCode >= http.StatusBadRequest {
+		err = newRestError(method, rq.URL.Path, rs.StatusCode, buf.Bytes())
+	}
+	if err != nil || handleBody == nil || rs.StatusCode == http.StatusNoContent {
+		return rs, err
+	}
+	return rs, handleBody(buf.Bytes())
 }
 
 func (c *restClientImpl) doJSON(ctx context.Context, method string, path string, rqBody any, rsBody any) error {
 	var rawRqBody []byte
 	if rqBody != nil {
-		var err error
-		rawRqBody, err = json.Marshal(rqBody)
-		if err != nil {
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if err := json.NewEncoder(buf).Encode(rqBody); err != nil {
 			return fmt.Errorf("failed to marshal request body: %w", err)
 		}
+		// the transport may still read the body after do returned, so it must not share memory with the pooled buffer
+		rawRqBody = bytes.Clone(buf.Bytes())
 	}
-	statusCode, rawBody, err := c.do(ctx, method, path, rawRqBody)
-	if err != nil {
-		return err
-	}
-	if statusCode != http.StatusNoContent {
-		if err = json.Unmarshal(rawBody, rsBody); err != nil {
-			return fmt.Errorf("failed to unmarshal response body: %w", err)
+
+	var handleBody func(body []byte) error
+	if rsBody != nil {
+		handleBody = func(body []byte) error {
+			if err := json.Unmarshal(body, rsBody); err != nil {
+				return fmt.Errorf("failed to unmarshal response body: %w", err)
+			}
+			return nil
 		}
 	}
-	return json.Unmarshal(rawBody, rsBody)
+	return c.do(ctx, method, path, rawRqBody, handleBody)
+}
+
+// maxPooledBufferSize prevents keeping buffers of unusually large responses alive in the pool.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
 }
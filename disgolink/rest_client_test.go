@@ -0,0 +1,149 @@
+package disgolink
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/json"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTrackJSON      = `{"encoded":"QAAAjQIAJVJpY2sgQXN0bGV5IC0gTmV2ZXIgR29ubmEgR2l2ZSBZb3UgVXAADlJpY2tBc3RsZXlWRVZPAAAAAAADPCAAC2RRdzR3OVdnWGNRAAEAK2h0dHBzOi8vd3d3LnlvdXR1YmUuY29tL3dhdGNoP3Y9ZFF3NHc5V2dYY1EAB3lvdXR1YmUAAAAAAAAAAA==","info":{"identifier":"dQw4w9WgXcQ","isSeekable":true,"author":"RickAstleyVEVO","length":212000,"isStream":false,"position":0,"title":"Rick Astley - Never Gonna Give You Up","uri":"https://www.youtube.com/watch?v=dQw4w9WgXcQ","artworkUrl":"https://i.ytimg.com/vi/dQw4w9WgXcQ/maxresdefault.jpg","isrc":null,"sourceName":"youtube"},"pluginInfo":{},"userData":{}}`
+	testLoadResultJSON = `{"loadType":"search","data":[` + testTrackJSON + `,` + testTrackJSON + `,` + testTrackJSON + `,` + testTrackJSON + `,` + testTrackJSON + `]}`
+	testPlayerJSON     = `{"guildId":"817327181659111454","track":` + testTrackJSON + `,"volume":100,"paused":false,"state":{"time":1500467109,"position":60000,"connected":true,"ping":50},"voice":{"token":"token","endpoint":"rotterdam1234.discord.media","sessionId":"session"},"filters":{}}`
+)
+
+func newTestRestClient(tb testing.TB, body string) RestClient {
+	return newTestNode(tb, body).rest
+}
+
+func newTestNode(tb testing.TB, body string, opts ...ConfigOpt) *nodeImpl {
+	return newTestNodeWithHandler(tb, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}, opts...)
+}
+
+func newTestNodeWithHandler(tb testing.TB, handler http.HandlerFunc, opts ...ConfigOpt) *nodeImpl {
+	srv := httptest.NewServer(handler)
+	tb.Cleanup(srv.Close)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	client := New(snowflake.ID(1), append([]ConfigOpt{WithLogger(logger)}, opts...)...).(*clientImpl)
+	node := &nodeImpl{
+		logger:    logger,
+		lavalink:  client,
+		config:    NodeConfig{Name: "test", Address: strings.TrimPrefix(srv.URL, "http://")},
+		sessionID: "session",
+	}
+	node.rest = newRestClient(logger, node, srv.Client(), RestRetryConfig{}, nil)
+	return node
+}
+
+func TestRestClient_LoadTracks(t *testing.T) {
+	rest := newTestRestClient(t, testLoadResultJSON)
+
+	result, err := rest.LoadTracks(context.Background(), "ytsearch:Rick Astley - Never Gonna Give You Up")
+	assert.NoError(t, err)
+	assert.Equal(t, lavalink.LoadTypeSearch, result.LoadType)
+	if assert.IsType(t, lavalink.Search{}, result.Data) {
+		assert.Len(t, result.Data.(lavalink.Search), 5)
+	}
+}
+
+func TestRestClient_Middlewares(t *testing.T) {
+	var calls atomic.Int32
+	node := newTestNodeWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"players":1}`))
+	})
+
+	var order []string
+	record := func(name string) RestMiddleware {
+		return func(next RestHandler) RestHandler {
+			return func(rq *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next(rq)
+			}
+		}
+	}
+	rest := newRestClient(node.logger, node, http.DefaultClient, RestRetryConfig{}, []RestMiddleware{record("a"), record("b")})
+	_, err := rest.Stats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, order)
+	assert.Equal(t, int32(1), calls.Load())
+
+	shortCircuit := func(next RestHandler) RestHandler {
+		return func(rq *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"players":2}`)),
+			}, nil
+		}
+	}
+	rest = newRestClient(node.logger, node, http.DefaultClient, RestRetryConfig{}, []RestMiddleware{shortCircuit, record("c")})
+	stats, err := rest.Stats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Players)
+	assert.Equal(t, []string{"a", "b"}, order)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRestClient_DoRestErrorWithoutResponse(t *testing.T) {
+	node := newTestNode(t, "")
+	restErr := newRestError(http.MethodGet, "/v4/info", http.StatusServiceUnavailable, nil)
+	rest := newRestClient(node.logger, node, http.DefaultClient, RestRetryConfig{}, []RestMiddleware{
+		func(next RestHandler) RestHandler {
+			return func(rq *http.Request) (*http.Response, error) {
+				return nil, restErr
+			}
+		},
+	})
+
+	rq := httptest.NewRequest(http.MethodGet, "/v4/info", nil)
+	rs, err := rest.Do(rq)
+	assert.Nil(t, rs)
+	assert.ErrorIs(t, err, restErr)
+}
+
+func BenchmarkRestClient_LoadTracks(b *testing.B) {
+	rest := newTestRestClient(b, testLoadResultJSON)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rest.LoadTracks(ctx, "ytsearch:Rick Astley - Never Gonna Give You Up"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRestClient_UpdatePlayer(b *testing.B) {
+	rest := newTestRestClient(b, testPlayerJSON)
+	ctx := context.Background()
+	update := lavalink.PlayerUpdate{
+		Volume: json.Ptr(50),
+		Paused: json.Ptr(false),
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rest.UpdatePlayer(ctx, "session", snowflake.ID(817327181659111454), update); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package disgolink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrNotFound        = errors.New("not found")
+	ErrSessionNotFound = errors.New("session not found")
+	ErrPlayerNotFound  = errors.New("player not found")
+	ErrServerError     = errors.New("server error")
+)
+
+// RestError is returned by the RestClient when Lavalink responds with a status code of 400 or above.
+// Use errors.Is to check for ErrBadRequest, ErrUnauthorized, ErrNotFound, ErrSessionNotFound, ErrPlayerNotFound or ErrServerError
+// and errors.As to get the lavalink.Error.
+type RestError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	// Lavalink is the error sent by Lavalink or nil if the response body is not a lavalink.Error.
+	Lavalink *lavalink.Error
+	// Body is the raw response body if it is not a lavalink.Error.
+	Body []byte
+}
+
+func (e *RestError) Error() string {
+	if e.Lavalink != nil {
+		return fmt.Sprintf("%s %s: %d: %s", e.Method, e.Path, e.StatusCode, e.Lavalink.Error())
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *RestError) Unwrap() []error {
+	var errs []error
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		errs = append(errs, ErrBadRequest)
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		errs = append(errs, ErrUnauthorized)
+	case e.StatusCode == http.StatusNotFound:
+		errs = append(errs, ErrNotFound)
+		if e.Lavalink != nil {
+			message := strings.ToLower(e.Lavalink.Message)
+			if strings.Contains(message, "session") {
+				errs = append(errs, ErrSessionNotFound)
+			} else if strings.Contains(message, "player") {
+				errs = append(errs, ErrPlayerNotFound)
+			}
+		}
+	case e.StatusCode >= http.StatusInternalServerError:
+		errs = append(errs, ErrServerError)
+	}
+	if e.Lavalink != nil {
+		errs = append(errs, *e.Lavalink)
+	}
+	return errs
+}
+
+// newRestError creates a RestError from a response body which may or may not be a lavalink.Error.
+func newRestError(method string, path string, statusCode int, rawBody []byte) *RestError {
+	restErr := &RestError{
+		StatusCode: statusCode,
+		Method:     method,
+		Path:       path,
+	}
+	var lavalinkErr lavalink.Error
+	if err := json.Unmarshal(rawBody, &lavalinkErr); err == nil && lavalinkErr.Status != 0 {
+		restErr.Lavalink = &lavalinkErr
+	} else {
+		// the body may be backed by a pooled buffer
+		restErr.Body = bytes.Clone(rawBody)
+	}
+	return restErr
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context which makes the RestClient request stack traces from Lavalink, which are put into lavalink.Error.Trace.
+// Use NodeConfig.Trace to enable this for all requests to a Node.
+func WithTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey{}, true)
+}
+
+func traceEnabled(ctx context.Context) bool {
+	trace, _ := ctx.Value(traceKey{}).(bool)
+	return trace
+}
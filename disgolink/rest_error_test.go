@@ -0,0 +1,28 @@
+package disgolink
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestError_Is(t *testing.T) {
+	err := error(newRestError(http.MethodPatch, "/v4/sessions/abc/players/1", http.StatusNotFound, []byte(`{"timestamp":1667857581613,"status":404,"error":"Not Found","message":"Session not found","path":"/v4/sessions/abc/players/1"}`)))
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.NotErrorIs(t, err, ErrPlayerNotFound)
+
+	var lavalinkErr lavalink.Error
+	assert.True(t, errors.As(err, &lavalinkErr))
+	assert.Equal(t, "Session not found", lavalinkErr.Message)
+
+	err = newRestError(http.MethodGet, "/v4/info", http.StatusBadGateway, []byte("<html>502 Bad Gateway</html>"))
+	assert.ErrorIs(t, err, ErrServerError)
+	var restErr *RestError
+	assert.True(t, errors.As(err, &restErr))
+	assert.Equal(t, http.StatusBadGateway, restErr.StatusCode)
+	assert.Nil(t, restErr.Lavalink)
+}
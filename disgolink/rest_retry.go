@@ -0,0 +1,85 @@
+package disgolink
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RestRetryConfig configures how failed rest requests are retried.
+// Requests are retried on network errors, 429 and 5xx responses. Only GET requests and destroying players are retried by default,
+// as they are safe to repeat.
+type RestRetryConfig struct {
+	// MaxRetries is the maximum number of retries per request. 0 disables retrying.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry. It doubles with each retry. Defaults to 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Requests are not retried if the Retry-After header of the response exceeds it. Defaults to 5s.
+	MaxBackoff time.Duration
+	// RetryPlayerUpdates also retries player updates.
+	RetryPlayerUpdates bool
+	// AttemptTimeout limits how long each attempt may take. The context passed by the caller still limits the request as a whole.
+	AttemptTimeout time.Duration
+}
+
+func (c RestRetryConfig) retryable(method string, path string) bool {
+	switch method {
+	case http.MethodGet:
+		return true
+	case http.MethodDelete:
+		return strings.Contains(path, "/players/")
+	case http.MethodPatch:
+		return c.RetryPlayerUpdates && strings.Contains(path, "/players/")
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry, preferring the Retry-After header of the response.
+// It returns false if the node asks to wait longer than MaxBackoff, as retrying earlier would be rate limited again.
+func (c RestRetryConfig) backoff(retry int, rs *http.Response) (time.Duration, bool) {
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Second
+	}
+	if rs != nil {
+		if retryAfter := parseRetryAfter(rs.Header.Get("Retry-After")); retryAfter > 0 {
+			return retryAfter, retryAfter <= maxBackoff
+		}
+	}
+
+	minBackoff := c.MinBackoff
+	if minBackoff <= 0 {
+		minBackoff = 250 * time.Millisecond
+	}
+	delay, _ := (&ExponentialBackoff{
+		MinDelay:   minBackoff,
+		MaxDelay:   maxBackoff,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}).NextDelay(retry, 0)
+	return delay, true
+}
+
+func shouldRetry(statusCode int, err error) bool {
+	if statusCode == 0 {
+		// network error, but not if the caller gave up
+		return err != nil && !errors.Is(err, context.Canceled)
+	}
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+func parseRetryAfter(retryAfter string) time.Duration {
+	if retryAfter == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,109 @@
+package disgolink
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRetryRestClient(tb testing.TB, retry RestRetryConfig, statusCodes ...int) (RestClient, *atomic.Int32) {
+	var calls atomic.Int32
+	node := newTestNodeWithHandler(tb, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		call := int(calls.Add(1)) - 1
+		if call < len(statusCodes) {
+			if statusCodes[call] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			w.WriteHeader(statusCodes[call])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	})
+	return newRestClient(node.logger, node, http.DefaultClient, retry, nil), &calls
+}
+
+func TestRestClient_RetryServerError(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 3, MinBackoff: time.Millisecond}, http.StatusBadGateway, http.StatusServiceUnavailable)
+
+	_, err := rest.Players(context.Background(), "session")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRestClient_RetryTooManyRequests(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 1, MaxBackoff: 2 * time.Second}, http.StatusTooManyRequests)
+
+	start := time.Now()
+	_, err := rest.Players(context.Background(), "session")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "Retry-After should be honoured")
+}
+
+func TestRestClient_RetryTooManyRequestsExceedsMaxBackoff(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 1, MaxBackoff: 10 * time.Millisecond}, http.StatusTooManyRequests)
+
+	_, err := rest.Players(context.Background(), "session")
+	var restErr *RestError
+	if assert.ErrorAs(t, err, &restErr) {
+		assert.Equal(t, http.StatusTooManyRequests, restErr.StatusCode)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRestClient_RetryNotIdempotent(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 3, MinBackoff: time.Millisecond}, http.StatusServiceUnavailable)
+
+	err := rest.FreeAllRoutePlannerAddresses(context.Background())
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRestClient_RetryMaxRetries(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond},
+		http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError,
+	)
+
+	_, err := rest.Players(context.Background(), "session")
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRestClient_RetryContextDone(t *testing.T) {
+	rest, calls := newTestRetryRestClient(t, RestRetryConfig{MaxRetries: 3, MinBackoff: time.Minute, MaxBackoff: time.Minute}, http.StatusServiceUnavailable)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := rest.Players(ctx, "session")
+	assert.ErrorIs(t, err, ErrServerError)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestRestRetryConfig_Backoff(t *testing.T) {
+	retry := RestRetryConfig{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	rs := &http.Response{Header: http.Header{"Retry-After": []string{"1"}}}
+	delay, ok := retry.backoff(1, rs)
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+
+	rs.Header.Set("Retry-After", "3")
+	_, ok = retry.backoff(1, rs)
+	assert.False(t, ok)
+
+	rs.Header.Set("Retry-After", "invalid")
+	delay, ok = retry.backoff(1, rs)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, delay, 80*time.Millisecond)
+	assert.LessOrEqual(t, delay, 120*time.Millisecond)
+}
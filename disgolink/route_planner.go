@@ -0,0 +1,81 @@
+package disgolink
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// IsRateLimited reports whether the exception looks like the source responded with HTTP 429 Too Many Requests, which happens when YouTube rate limits an address.
+func IsRateLimited(exception lavalink.Exception) bool {
+	texts := []string{exception.Message}
+	if exception.Cause != nil {
+		texts = append(texts, *exception.Cause)
+	}
+	for _, text := range texts {
+		if strings.Contains(text, "429") || strings.Contains(strings.ToLower(text), "too many requests") {
+			return true
+		}
+	}
+	return false
+}
+
+// routePlannerFreeInterval is the minimum time between freeing the addresses of the same node, as one rate limit usually fails many tracks at once.
+const routePlannerFreeInterval = 30 * time.Second
+
+// NewRoutePlannerListener returns an EventListener which frees all failing addresses of the route planner of a Node
+// when a lavalink.TrackExceptionEvent on it looks like rate limiting. See IsRateLimited.
+// Addresses of a node are freed at most once every 30 seconds.
+func NewRoutePlannerListener(logger *slog.Logger) EventListener {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var (
+		mu        sync.Mutex
+		lastFreed = map[string]time.Time{}
+	)
+	return NewListenerFunc(func(player Player, event lavalink.TrackExceptionEvent) {
+		if !IsRateLimited(event.Exception) {
+			return
+		}
+		node := player.Node()
+		if node == nil {
+			return
+		}
+
+		name := node.Config().Name
+		mu.Lock()
+		if time.Since(lastFreed[name]) < routePlannerFreeInterval {
+			mu.Unlock()
+			return
+		}
+		lastFreed[name] = time.Now()
+		mu.Unlock()
+
+		// do not block the node's read loop with rest requests
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			status, err := node.RoutePlannerStatus(ctx)
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to get route planner status", slog.Any("err", err), slog.String("node_name", name))
+				return
+			}
+			if status == nil || len(status.FailingAddresses()) == 0 {
+				return
+			}
+
+			if err = node.FreeAllRoutePlannerAddresses(ctx); err != nil {
+				logger.ErrorContext(ctx, "failed to free route planner addresses", slog.Any("err", err), slog.String("node_name", name))
+				return
+			}
+			logger.InfoContext(ctx, "freed rate limited route planner addresses", slog.String("node_name", name), slog.Int("addresses", len(status.FailingAddresses())))
+		}()
+	})
+}
@@ -0,0 +1,105 @@
+package disgolink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// SessionStore persists the session ids of nodes so sessions can be resumed after a restart.
+type SessionStore interface {
+	// Load returns the stored session id of the node or an empty string if none is stored.
+	Load(nodeName string) (string, error)
+	// Save stores the session id of the node.
+	Save(nodeName string, sessionID string) error
+}
+
+var (
+	_ SessionStore = (*memorySessionStore)(nil)
+	_ SessionStore = (*fileSessionStore)(nil)
+)
+
+// NewMemorySessionStore returns a SessionStore which keeps the session ids in memory.
+func NewMemorySessionStore() SessionStore {
+	return &memorySessionStore{
+		sessions: map[string]string{},
+	}
+}
+
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+func (s *memorySessionStore) Load(nodeName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[nodeName], nil
+}
+
+func (s *memorySessionStore) Save(nodeName string, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[nodeName] = sessionID
+	return nil
+}
+
+// NewFileSessionStore returns a SessionStore which keeps the session ids of all nodes in a json file at the given path.
+func NewFileSessionStore(path string) SessionStore {
+	return &fileSessionStore{
+		path: path,
+	}
+}
+
+type fileSessionStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (s *fileSessionStore) Load(nodeName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	return sessions[nodeName], nil
+}
+
+func (s *fileSessionStore) Save(nodeName string, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions, err := s.read()
+	if err != nil {
+		return err
+	}
+	sessions[nodeName] = sessionID
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sessions: %w", err)
+	}
+	if err = os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write sessions: %w", err)
+	}
+	return nil
+}
+
+func (s *fileSessionStore) read() (map[string]string, error) {
+	sessions := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return sessions, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	if err = json.Unmarshal(data, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sessions: %w", err)
+	}
+	return sessions, nil
+}
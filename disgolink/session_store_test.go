@@ -0,0 +1,59 @@
+package disgolink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+	store := NewMemorySessionStore()
+
+	sessionID, err := store.Load("test")
+	require.NoError(t, err)
+	assert.Empty(t, sessionID)
+
+	require.NoError(t, store.Save("test", "session"))
+	sessionID, err = store.Load("test")
+	require.NoError(t, err)
+	assert.Equal(t, "session", sessionID)
+}
+
+func TestFileSessionStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	sessionID, err := NewFileSessionStore(path).Load("test")
+	require.NoError(t, err, "a missing file should be treated as empty")
+	assert.Empty(t, sessionID)
+
+	store := NewFileSessionStore(path)
+	require.NoError(t, store.Save("test", "session"))
+	require.NoError(t, store.Save("other", "other-session"))
+
+	// a new store has to read the sessions from the file
+	store = NewFileSessionStore(path)
+	sessionID, err = store.Load("test")
+	require.NoError(t, err)
+	assert.Equal(t, "session", sessionID)
+	sessionID, err = store.Load("other")
+	require.NoError(t, err)
+	assert.Equal(t, "other-session", sessionID)
+}
+
+func TestFileSessionStore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+
+	_, err := NewFileSessionStore(path).Load("test")
+	assert.Error(t, err)
+}
+
+func TestWithSessionStore_DefaultTimeout(t *testing.T) {
+	config := DefaultConfig()
+	WithSessionStore(NewMemorySessionStore(), 0)(config)
+	assert.Equal(t, 60*time.Second, config.ResumeTimeout)
+}
@@ -0,0 +1,140 @@
+package disgolink
+
+import (
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// StatsSample is a lavalink.Stats with the time it was received.
+type StatsSample struct {
+	Stats lavalink.Stats
+	Time  time.Time
+}
+
+// StatsSummary contains the rolling averages of all samples in a StatsHistory.
+type StatsSummary struct {
+	Samples int
+	// CPULoad is the average system load of the node between 0 and 1.
+	CPULoad float64
+	// MemoryHeadroom is the average share of reservable memory which is not used between 0 and 1.
+	MemoryHeadroom float64
+	// FrameDeficitRatio is the share of expected audio frames which were not sent or nulled between 0 and 1.
+	FrameDeficitRatio float64
+}
+
+func newStatsHistory(size int) *StatsHistory {
+	if size <= 0 {
+		size = 1
+	}
+	return &StatsHistory{
+		samples: make([]StatsSample, 0, size),
+	}
+}
+
+// StatsHistory is a ring buffer of the most recent lavalink.Stats of a Node.
+type StatsHistory struct {
+	mu      sync.Mutex
+	samples []StatsSample
+	next    int
+}
+
+func (h *StatsHistory) add(sample StatsSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, sample)
+		return
+	}
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+}
+
+// Samples returns all samples ordered from oldest to newest.
+func (h *StatsHistory) Samples() []StatsSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := make([]StatsSample, 0, len(h.samples))
+	samples = append(samples, h.samples[h.next:]...)
+	return append(samples, h.samples[:h.next]...)
+}
+
+// Latest returns the newest sample or false if there is none.
+func (h *StatsHistory) Latest() (StatsSample, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.samples) == 0 {
+		return StatsSample{}, false
+	}
+	return h.samples[(h.next+len(h.samples)-1)%len(h.samples)], true
+}
+
+// Summary returns the rolling averages of all samples.
+func (h *StatsHistory) Summary() StatsSummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	summary := StatsSummary{Samples: len(h.samples)}
+	if len(h.samples) == 0 {
+		return summary
+	}
+
+	var memorySamples, expectedFrames, missingFrames int
+	for _, sample := range h.samples {
+		summary.CPULoad += sample.Stats.CPU.SystemLoad
+		if sample.Stats.Memory.Reservable > 0 {
+			summary.MemoryHeadroom += float64(sample.Stats.Memory.Reservable-sample.Stats.Memory.Used) / float64(sample.Stats.Memory.Reservable)
+			memorySamples++
+		}
+		if frames := sample.Stats.FrameStats; frames != nil {
+			// lavalink defines the deficit as expected - (sent + nulled), nulled frames are silent and count as missing as well
+			expectedFrames += frames.Sent + frames.Nulled + frames.Deficit
+			missingFrames += frames.Deficit + frames.Nulled
+		}
+	}
+	summary.CPULoad /= float64(len(h.samples))
+	if memorySamples > 0 {
+		summary.MemoryHeadroom /= float64(memorySamples)
+	}
+	if expectedFrames > 0 {
+		summary.FrameDeficitRatio = float64(missingFrames) / float64(expectedFrames)
+	}
+	return summary
+}
+
+// HealthReason is the reason why a Node is considered degraded.
+type HealthReason string
+
+const (
+	HealthReasonCPULoad           HealthReason = "cpu_load"
+	HealthReasonMemoryHeadroom    HealthReason = "memory_headroom"
+	HealthReasonFrameDeficitRatio HealthReason = "frame_deficit_ratio"
+)
+
+// HealthThresholds configure when a Node is considered degraded based on its StatsSummary. Zero values disable the threshold.
+type HealthThresholds struct {
+	MaxCPULoad           float64
+	MinMemoryHeadroom    float64
+	MaxFrameDeficitRatio float64
+}
+
+// Enabled returns true if any threshold is set.
+func (t HealthThresholds) Enabled() bool {
+	return t.MaxCPULoad > 0 || t.MinMemoryHeadroom > 0 || t.MaxFrameDeficitRatio > 0
+}
+
+// Check returns the reasons why the summary exceeds the thresholds or nil if it does not.
+func (t HealthThresholds) Check(summary StatsSummary) []HealthReason {
+	var reasons []HealthReason
+	if t.MaxCPULoad > 0 && summary.CPULoad > t.MaxCPULoad {
+		reasons = append(reasons, HealthReasonCPULoad)
+	}
+	if t.MinMemoryHeadroom > 0 && summary.MemoryHeadroom < t.MinMemoryHeadroom {
+		reasons = append(reasons, HealthReasonMemoryHeadroom)
+	}
+	if t.MaxFrameDeficitRatio > 0 && summary.FrameDeficitRatio > t.MaxFrameDeficitRatio {
+		reasons = append(reasons, HealthReasonFrameDeficitRatio)
+	}
+	return reasons
+}
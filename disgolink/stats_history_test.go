@@ -0,0 +1,72 @@
+package disgolink
+
+import (
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/stretchr/testify/assert"
+)
+
+func testStats(systemLoad float64, used int, deficit int) lavalink.Stats {
+	return lavalink.Stats{
+		Memory:     lavalink.Memory{Used: used, Reservable: 100},
+		CPU:        lavalink.CPU{Cores: 1, SystemLoad: systemLoad},
+		FrameStats: &lavalink.FrameStats{Sent: 3000 - deficit, Deficit: deficit},
+	}
+}
+
+func TestStatsHistory(t *testing.T) {
+	history := newStatsHistory(2)
+	_, ok := history.Latest()
+	assert.False(t, ok)
+
+	history.add(StatsSample{Stats: testStats(0.9, 90, 3000)})
+	history.add(StatsSample{Stats: testStats(0.2, 50, 0)})
+	history.add(StatsSample{Stats: testStats(0.4, 70, 300)})
+
+	samples := history.Samples()
+	if assert.Len(t, samples, 2) {
+		assert.Equal(t, 0.2, samples[0].Stats.CPU.SystemLoad)
+		assert.Equal(t, 0.4, samples[1].Stats.CPU.SystemLoad)
+	}
+	latest, ok := history.Latest()
+	assert.True(t, ok)
+	assert.Equal(t, 0.4, latest.Stats.CPU.SystemLoad)
+
+	summary := history.Summary()
+	assert.Equal(t, 2, summary.Samples)
+	assert.InDelta(t, 0.3, summary.CPULoad, 0.0001)
+	assert.InDelta(t, 0.4, summary.MemoryHeadroom, 0.0001)
+	assert.InDelta(t, 0.05, summary.FrameDeficitRatio, 0.0001)
+}
+
+func TestStatsHistory_NulledFrames(t *testing.T) {
+	history := newStatsHistory(2)
+	history.add(StatsSample{Stats: lavalink.Stats{FrameStats: &lavalink.FrameStats{Nulled: 3000}}})
+	history.add(StatsSample{Stats: lavalink.Stats{FrameStats: &lavalink.FrameStats{Sent: 2400, Nulled: 300, Deficit: 300}}})
+
+	assert.InDelta(t, 0.6, history.Summary().FrameDeficitRatio, 0.0001)
+}
+
+func TestNodeHealthEvents(t *testing.T) {
+	var events []lavalink.Message
+	node := newTestNode(t, "", WithListenerFunc(func(p Player, e NodeDegradedEvent) {
+		events = append(events, e)
+	}), WithListenerFunc(func(p Player, e NodeRecoveredEvent) {
+		events = append(events, e)
+	}))
+	node.statsHistory = newStatsHistory(2)
+	node.healthThresholds = HealthThresholds{MaxFrameDeficitRatio: 0.1}
+
+	node.recordStats(testStats(0.1, 10, 0))
+	node.recordStats(testStats(0.1, 10, 1500))
+	node.recordStats(testStats(0.1, 10, 1500))
+	node.recordStats(testStats(0.1, 10, 0))
+	node.recordStats(testStats(0.1, 10, 0))
+
+	if assert.Len(t, events, 2) {
+		degraded := events[0].(NodeDegradedEvent)
+		assert.Equal(t, []HealthReason{HealthReasonFrameDeficitRatio}, degraded.Reasons)
+		assert.IsType(t, NodeRecoveredEvent{}, events[1])
+	}
+}
@@ -0,0 +1,111 @@
+package disgolink
+
+import (
+	"context"
+	"sync"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+)
+
+// SubscriptionBufferSize is the buffer size of channels returned by Subscribe. Once the buffer is full event dispatching blocks until the
+// subscriber receives or its context is done, so consider using WithDispatcher.
+var SubscriptionBufferSize = 16
+
+// Subscribe returns a channel which receives all events of type E that match the filter. A nil filter matches all events.
+// The channel is closed once the context is done.
+func Subscribe[E lavalink.Message](ctx context.Context, client Client, filter func(player Player, event E) bool) <-chan E {
+	s := &subscription[E]{
+		ctx:    ctx,
+		filter: filter,
+		ch:     make(chan E, SubscriptionBufferSize),
+	}
+	handle := client.AddListeners(s)
+	go func() {
+		<-ctx.Done()
+		handle.Remove()
+		s.close()
+	}()
+	return s.ch
+}
+
+// SubscribeGuild is like Subscribe but only receives events of the given guild.
+func SubscribeGuild[E lavalink.Message](ctx context.Context, client Client, guildID snowflake.ID, filter func(player Player, event E) bool) <-chan E {
+	return Subscribe(ctx, client, func(player Player, event E) bool {
+		return eventGuildID(player, event) == guildID && (filter == nil || filter(player, event))
+	})
+}
+
+// SubscribeNode is like Subscribe but only receives NodeEvent(s) of the given node and events of players on it.
+func SubscribeNode[E lavalink.Message](ctx context.Context, client Client, node Node, filter func(player Player, event E) bool) <-chan E {
+	name := node.Config().Name
+	return Subscribe(ctx, client, func(player Player, event E) bool {
+		var eventNode Node
+		if nodeEvent, ok := any(event).(NodeEvent); ok {
+			eventNode = nodeEvent.Node()
+		} else if player != nil {
+			eventNode = player.Node()
+		}
+		return eventNode != nil && eventNode.Config().Name == name && (filter == nil || filter(player, event))
+	})
+}
+
+// AwaitEvent returns the next event of type E of the player's guild. Use a context with a timeout to stop waiting, in which case the context error is returned.
+// Events emitted before AwaitEvent is called are not received, so start waiting before triggering the event, for example in a goroutine, or use SubscribeGuild.
+func AwaitEvent[E lavalink.Message](ctx context.Context, player Player) (E, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	select {
+	case event, ok := <-SubscribeGuild[E](ctx, player.Lavalink(), player.GuildID(), nil):
+		if ok {
+			return event, nil
+		}
+	case <-ctx.Done():
+	}
+	var zero E
+	return zero, ctx.Err()
+}
+
+func eventGuildID(player Player, event lavalink.Message) snowflake.ID {
+	if player != nil {
+		return player.GuildID()
+	}
+	if e, ok := event.(interface{ GuildID() snowflake.ID }); ok {
+		return e.GuildID()
+	}
+	return 0
+}
+
+type subscription[E lavalink.Message] struct {
+	ctx    context.Context
+	filter func(player Player, event E) bool
+
+	mu     sync.Mutex
+	closed bool
+	ch     chan E
+}
+
+func (s *subscription[E]) OnEvent(player Player, event lavalink.Message) {
+	e, ok := event.(E)
+	if !ok || (s.filter != nil && !s.filter(player, e)) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- e:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *subscription[E]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
@@ -0,0 +1,70 @@
+package disgolink
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeGuild(t *testing.T) {
+	client := newTestClient()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events := SubscribeGuild(ctx, client, 1, func(player Player, event lavalink.TrackEndEvent) bool {
+		return event.Reason == lavalink.TrackEndReasonFinished
+	})
+	client.EmitEvent(nil, lavalink.TrackStartEvent{GuildID_: 1})
+	client.EmitEvent(nil, lavalink.TrackEndEvent{GuildID_: 2, Reason: lavalink.TrackEndReasonFinished})
+	client.EmitEvent(nil, lavalink.TrackEndEvent{GuildID_: 1, Reason: lavalink.TrackEndReasonStopped})
+	client.EmitEvent(nil, lavalink.TrackEndEvent{GuildID_: 1, Reason: lavalink.TrackEndReasonFinished})
+
+	assert.Equal(t, snowflake.ID(1), (<-events).GuildID())
+	cancel()
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Eventually(t, func() bool {
+		client.listenersMu.Lock()
+		defer client.listenersMu.Unlock()
+		return len(client.listeners) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestSubscribeNode(t *testing.T) {
+	node := newTestNode(t, "")
+	client := node.lavalink.(*clientImpl)
+
+	otherNode := newTestNode(t, "")
+	otherNode.config.Name = "other"
+
+	events := SubscribeNode[NodeEvent](context.Background(), client, node, nil)
+	client.EmitEvent(nil, NodeReadyEvent{Node_: otherNode})
+	client.EmitEvent(nil, NodeReadyEvent{Node_: node})
+
+	assert.Equal(t, NodeReadyEvent{Node_: node}, <-events)
+	assert.Len(t, events, 0)
+}
+
+func TestAwaitEvent(t *testing.T) {
+	node := newTestNode(t, "")
+	player := node.lavalink.PlayerOnNode(node, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		node.lavalink.EmitEvent(nil, lavalink.TrackStartEvent{GuildID_: 2})
+		node.lavalink.EmitEvent(player, lavalink.TrackStartEvent{GuildID_: 1})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, err := AwaitEvent[lavalink.TrackStartEvent](ctx, player)
+	assert.NoError(t, err)
+	assert.Equal(t, snowflake.ID(1), event.GuildID())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = AwaitEvent[lavalink.TrackStartEvent](ctx, player)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
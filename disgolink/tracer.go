@@ -0,0 +1,62 @@
+package disgolink
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// Attribute keys used in spans started by disgolink.
+const (
+	AttrNodeName   = "lavalink.node.name"
+	AttrGuildID    = "lavalink.guild_id"
+	AttrEndpoint   = "lavalink.endpoint"
+	AttrMethod     = "http.request.method"
+	AttrStatusCode = "http.response.status_code"
+	AttrAttempts   = "lavalink.attempts"
+	AttrEventType  = "lavalink.event.type"
+	AttrIdentifier = "lavalink.identifier"
+	AttrLoadType   = "lavalink.load_type"
+)
+
+// Tracer starts spans for rest requests, player updates, track loading, node reconnects and event dispatching.
+// It can be implemented by an adapter for a tracing library like OpenTelemetry.
+type Tracer interface {
+	// Start starts a new span which is a child of the span in the context and returns a context containing the new span.
+	Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span)
+}
+
+// Span is a single operation started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...slog.Attr)
+	RecordError(err error)
+	End()
+}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...slog.Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+
+// RestEndpoint returns the path without query and with session & guild ids replaced by placeholders.
+// It is used as span name & attribute to keep their cardinality low.
+func RestEndpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "sessions":
+			segments[i] = "{sessionId}"
+		case "players":
+			segments[i] = "{guildId}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
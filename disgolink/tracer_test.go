@@ -0,0 +1,90 @@
+package disgolink
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSpanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]string
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value.String()
+	}
+}
+
+func (s *testSpan) RecordError(error) {}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	span := &testSpan{name: name, attrs: map[string]string{}}
+	if parent, ok := ctx.Value(testSpanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	span.SetAttributes(attrs...)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, testSpanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &testTracer{}
+
+	node := newTestNode(t, testLoadResultJSON, WithTracer(tracer))
+	_, err := node.LoadTracks(context.Background(), "ytsearch:Rick Astley")
+	assert.NoError(t, err)
+
+	node = newTestNode(t, testPlayerJSON, WithTracer(tracer))
+	player := NewPlayer(node.logger, node.lavalink, node, snowflake.ID(817327181659111454))
+	ctx := context.WithValue(context.Background(), testSpanKey{}, &testSpan{name: "command"})
+	assert.NoError(t, player.Update(ctx, lavalink.WithVolume(50)))
+
+	traceCtx := traceContext(player)
+	assert.Equal(t, "lavalink.player.update", traceCtx.Value(testSpanKey{}).(*testSpan).name)
+
+	if assert.Len(t, tracer.spans, 4) {
+		assert.Equal(t, "lavalink.load_tracks", tracer.spans[0].name)
+		assert.Equal(t, "search", tracer.spans[0].attrs[AttrLoadType])
+		assert.Equal(t, "GET /v4/loadtracks", tracer.spans[1].name)
+		assert.Equal(t, "lavalink.load_tracks", tracer.spans[1].parent)
+		assert.Equal(t, "200", tracer.spans[1].attrs[AttrStatusCode])
+
+		assert.Equal(t, "lavalink.player.update", tracer.spans[2].name)
+		assert.Equal(t, "command", tracer.spans[2].parent)
+		assert.Equal(t, "817327181659111454", tracer.spans[2].attrs[AttrGuildID])
+		assert.Equal(t, "PATCH /v4/sessions/{sessionId}/players/{guildId}", tracer.spans[3].name)
+		assert.Equal(t, "lavalink.player.update", tracer.spans[3].parent)
+		assert.Equal(t, "test", tracer.spans[3].attrs[AttrNodeName])
+	}
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended, span.name)
+	}
+}
+
+func TestRestEndpoint(t *testing.T) {
+	assert.Equal(t, "/v4/loadtracks", RestEndpoint("/v4/loadtracks?identifier=test"))
+	assert.Equal(t, "/v4/sessions/{sessionId}/players/{guildId}", RestEndpoint("/v4/sessions/abc/players/123?noReplace=true"))
+}
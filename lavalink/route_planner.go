@@ -0,0 +1,137 @@
+package lavalink
+
+import (
+	"fmt"
+
+	"github.com/disgoorg/json"
+)
+
+type RoutePlannerType string
+
+const (
+	RoutePlannerTypeRotatingIP     RoutePlannerType = "RotatingIpRoutePlanner"
+	RoutePlannerTypeNanoIP         RoutePlannerType = "NanoIpRoutePlanner"
+	RoutePlannerTypeRotatingNanoIP RoutePlannerType = "RotatingNanoIpRoutePlanner"
+	RoutePlannerTypeBalancingIP    RoutePlannerType = "BalancingIpRoutePlanner"
+)
+
+type RoutePlannerDetails interface {
+	routePlannerDetails()
+}
+
+type RoutePlannerStatus struct {
+	Class   RoutePlannerType    `json:"class"`
+	Details RoutePlannerDetails `json:"details"`
+}
+
+func (s *RoutePlannerStatus) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Class   RoutePlannerType `json:"class"`
+		Details json.RawMessage  `json:"details"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	s.Class = raw.Class
+
+	var (
+		details RoutePlannerDetails
+		err     error
+	)
+	switch raw.Class {
+	case RoutePlannerTypeRotatingIP:
+		var d RotatingIPRoutePlannerDetails
+		err = json.Unmarshal(raw.Details, &d)
+		details = d
+	case RoutePlannerTypeNanoIP:
+		var d NanoIPRoutePlannerDetails
+		err = json.Unmarshal(raw.Details, &d)
+		details = d
+	case RoutePlannerTypeRotatingNanoIP:
+		var d RotatingNanoIPRoutePlannerDetails
+		err = json.Unmarshal(raw.Details, &d)
+		details = d
+	case RoutePlannerTypeBalancingIP:
+		var d BalancingIPRoutePlannerDetails
+		err = json.Unmarshal(raw.Details, &d)
+		details = d
+	default:
+		return fmt.Errorf("unknown route planner class %q", raw.Class)
+	}
+	if err != nil {
+		return err
+	}
+	s.Details = details
+	return nil
+}
+
+type IPBlockType string
+
+const (
+	IPBlockTypeInet4 IPBlockType = "Inet4Address"
+	IPBlockTypeInet6 IPBlockType = "Inet6Address"
+)
+
+type IPBlock struct {
+	Type IPBlockType `json:"type"`
+	Size string      `json:"size"`
+}
+
+type FailingAddress struct {
+	Address   string    `json:"failingAddress"`
+	Timestamp Timestamp `json:"failingTimestamp"`
+	Time      string    `json:"failingTime"`
+}
+
+type RotatingIPRoutePlannerDetails struct {
+	IPBlock          IPBlock          `json:"ipBlock"`
+	FailingAddresses []FailingAddress `json:"failingAddresses"`
+	RotateIndex      string           `json:"rotateIndex"`
+	IPIndex          string           `json:"ipIndex"`
+	CurrentAddress   string           `json:"currentAddress"`
+}
+
+func (RotatingIPRoutePlannerDetails) routePlannerDetails() {}
+
+type NanoIPRoutePlannerDetails struct {
+	IPBlock             IPBlock          `json:"ipBlock"`
+	FailingAddresses    []FailingAddress `json:"failingAddresses"`
+	CurrentAddressIndex string           `json:"currentAddressIndex"`
+}
+
+func (NanoIPRoutePlannerDetails) routePlannerDetails() {}
+
+type RotatingNanoIPRoutePlannerDetails struct {
+	IPBlock             IPBlock          `json:"ipBlock"`
+	FailingAddresses    []FailingAddress `json:"failingAddresses"`
+	BlockIndex          string           `json:"blockIndex"`
+	CurrentAddressIndex string           `json:"currentAddressIndex"`
+}
+
+func (RotatingNanoIPRoutePlannerDetails) routePlannerDetails() {}
+
+type BalancingIPRoutePlannerDetails struct {
+	IPBlock          IPBlock          `json:"ipBlock"`
+	FailingAddresses []FailingAddress `json:"failingAddresses"`
+}
+
+func (BalancingIPRoutePlannerDetails) routePlannerDetails() {}
+
+// FailingAddresses returns the failing addresses of the route planner or nil if there is no route planner.
+func (s RoutePlannerStatus) FailingAddresses() []FailingAddress {
+	switch d := s.Details.(type) {
+	case RotatingIPRoutePlannerDetails:
+		return d.FailingAddresses
+	case NanoIPRoutePlannerDetails:
+		return d.FailingAddresses
+	case RotatingNanoIPRoutePlannerDetails:
+		return d.FailingAddresses
+	case BalancingIPRoutePlannerDetails:
+		return d.FailingAddresses
+	}
+	return nil
+}
+
+type RoutePlannerFreeAddress struct {
+	Address string `json:"address"`
+}
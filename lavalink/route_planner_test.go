@@ -0,0 +1,31 @@
+package lavalink
+
+import (
+	"testing"
+
+	"github.com/disgoorg/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoutePlannerStatus_UnmarshalJSON(t *testing.T) {
+	data := []byte(`{
+		"class": "RotatingNanoIpRoutePlanner",
+		"details": {
+			"ipBlock": {"type": "Inet6Address", "size": "1208925819614629174706176"},
+			"failingAddresses": [{"failingAddress": "/1.0.0.0", "failingTimestamp": 1573520707545, "failingTime": "Mon Nov 11 20:05:07 EST 2019"}],
+			"blockIndex": "0",
+			"currentAddressIndex": "36792023813"
+		}
+	}`)
+
+	var status RoutePlannerStatus
+	assert.NoError(t, json.Unmarshal(data, &status))
+	assert.Equal(t, RoutePlannerTypeRotatingNanoIP, status.Class)
+
+	details, ok := status.Details.(RotatingNanoIPRoutePlannerDetails)
+	assert.True(t, ok)
+	assert.Equal(t, IPBlockTypeInet6, details.IPBlock.Type)
+	assert.Equal(t, "36792023813", details.CurrentAddressIndex)
+	assert.Len(t, status.FailingAddresses(), 1)
+	assert.Equal(t, "/1.0.0.0", status.FailingAddresses()[0].Address)
+}
@@ -9,11 +9,12 @@ type Stats struct {
 	FrameStats     *FrameStats `json:"frameStats"`
 }
 
+// Better returns true if s has a lower system load per core than stats.
 func (s Stats) Better(stats Stats) bool {
 	sLoad := int(s.CPU.SystemLoad / float64(s.CPU.Cores) * 100)
 	statsLoad := int(stats.CPU.SystemLoad / float64(stats.CPU.Cores) * 100)
 
-	return sLoad > statsLoad
+	return sLoad < statsLoad
 }
 
 type Memory struct {
@@ -0,0 +1,411 @@
+// Package metrics collects metrics of a disgolink.Client and exposes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the rest latency histogram buckets.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// MetricType is the type of MetricFamily.
+type MetricType string
+
+const (
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// MetricFamily is a group of metrics with the same name, like prometheus' dto.MetricFamily.
+type MetricFamily struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Metrics []Metric
+}
+
+// Metric is a single sample of a MetricFamily. Buckets, Count and Sum are only set for histograms.
+type Metric struct {
+	Labels  []Label
+	Value   float64
+	Buckets []Bucket
+	Count   uint64
+	Sum     float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Bucket is a cumulative histogram bucket.
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+var (
+	_ disgolink.EventListener = (*Collector)(nil)
+	_ http.Handler            = (*Collector)(nil)
+)
+
+// New returns a new Collector. All metric names are prefixed with the namespace, which defaults to disgolink.
+// Pass Collector.ConfigOpt to disgolink.New, the client is picked up from the first node event.
+func New(namespace string) *Collector {
+	if namespace == "" {
+		namespace = "disgolink"
+	}
+	return &Collector{
+		namespace:         namespace,
+		nodeNames:         map[string]string{},
+		reconnectAttempts: map[string]uint64{},
+		reconnects:        map[string]uint64{},
+		events:            map[eventKey]uint64{},
+		restLatencies:     map[restKey]*histogram{},
+	}
+}
+
+// Collector collects metrics from a disgolink.Client. Node stats, status and player counts are read when gathering,
+// while events and rest requests are counted as they happen.
+type Collector struct {
+	namespace string
+
+	mu                sync.Mutex
+	client            disgolink.Client
+	nodeNames         map[string]string
+	reconnectAttempts map[string]uint64
+	reconnects        map[string]uint64
+	events            map[eventKey]uint64
+	restLatencies     map[restKey]*histogram
+}
+
+type eventKey struct {
+	op        lavalink.Op
+	eventType string
+}
+
+type restKey struct {
+	node     string
+	method   string
+	endpoint string
+	status   string
+}
+
+// ConfigOpt registers the Collector as event listener and rest middleware of the client.
+func (c *Collector) ConfigOpt() disgolink.ConfigOpt {
+	return func(config *disgolink.Config) {
+		disgolink.WithListeners(c)(config)
+		disgolink.WithRestMiddlewares(c.RestMiddleware)(config)
+	}
+}
+
+func (c *Collector) OnEvent(player disgolink.Player, event lavalink.Message) {
+	var client disgolink.Client
+	if player != nil {
+		client = player.Lavalink()
+	}
+
+	var nodeConfig *disgolink.NodeConfig
+	key := eventKey{op: event.Op()}
+	switch e := event.(type) {
+	case lavalink.Event:
+		key.eventType = string(e.Type())
+	case disgolink.NodeEvent:
+		key.eventType = nodeEventType(e)
+		if node := e.Node(); node != nil {
+			client = node.Lavalink()
+			config := node.Config()
+			nodeConfig = &config
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil && client != nil {
+		c.client = client
+	}
+	if nodeConfig != nil {
+		// rest requests are labeled by the node name without calling back into the client
+		if _, ok := event.(disgolink.NodeRemovedEvent); ok {
+			delete(c.nodeNames, nodeConfig.Address)
+		} else {
+			c.nodeNames[nodeConfig.Address] = nodeConfig.Name
+		}
+	}
+	c.events[key]++
+
+	switch e := event.(type) {
+	case disgolink.NodeReconnectingEvent:
+		c.reconnectAttempts[e.Node().Config().Name]++
+	case disgolink.NodeReconnectedEvent:
+		c.reconnects[e.Node().Config().Name]++
+	}
+}
+
+func nodeEventType(event disgolink.NodeEvent) string {
+	switch event.(type) {
+	case disgolink.NodeConnectingEvent:
+		return "NodeConnectingEvent"
+	case disgolink.NodeReadyEvent:
+		return "NodeReadyEvent"
+	case disgolink.NodeDisconnectedEvent:
+		return "NodeDisconnectedEvent"
+	case disgolink.NodeRemovedEvent:
+		return "NodeRemovedEvent"
+	case disgolink.NodeErrorEvent:
+		return "NodeErrorEvent"
+	case disgolink.NodeReconnectingEvent:
+		return "NodeReconnectingEvent"
+	case disgolink.NodeReconnectedEvent:
+		return "NodeReconnectedEvent"
+	case disgolink.NodeReconnectFailedEvent:
+		return "NodeReconnectFailedEvent"
+	case disgolink.NodeDegradedEvent:
+		return "NodeDegradedEvent"
+	case disgolink.NodeRecoveredEvent:
+		return "NodeRecoveredEvent"
+	case disgolink.PlayersRestoredEvent:
+		return "PlayersRestoredEvent"
+	case disgolink.RestRetryEvent:
+		return "RestRetryEvent"
+	default:
+		return "unknown"
+	}
+}
+
+// RestMiddleware records the latency of rest requests by node, endpoint and status code.
+func (c *Collector) RestMiddleware(next disgolink.RestHandler) disgolink.RestHandler {
+	return func(rq *http.Request) (*http.Response, error) {
+		start := time.Now()
+		rs, err := next(rq)
+		duration := time.Since(start)
+
+		status := "error"
+		if rs != nil {
+			status = strconv.Itoa(rs.StatusCode)
+		}
+		key := restKey{
+			node:     c.nodeName(rq.URL.Host),
+			method:   rq.Method,
+			endpoint: disgolink.RestEndpoint(rq.URL.Path),
+			status:   status,
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		h, ok := c.restLatencies[key]
+		if !ok {
+			h = newHistogram(DefaultBuckets)
+			c.restLatencies[key] = h
+		}
+		h.observe(duration.Seconds())
+		return rs, err
+	}
+}
+
+func (c *Collector) nodeName(address string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name, ok := c.nodeNames[address]; ok {
+		return name
+	}
+	return address
+}
+
+// Gather returns the current metrics sorted by name.
+func (c *Collector) Gather() []MetricFamily {
+	c.mu.Lock()
+	client := c.client
+	c.mu.Unlock()
+
+	families := map[string]*MetricFamily{}
+	add := func(name string, help string, metricType MetricType, metric Metric) {
+		name = c.namespace + "_" + name
+		family, ok := families[name]
+		if !ok {
+			family = &MetricFamily{Name: name, Help: help, Type: metricType}
+			families[name] = family
+		}
+		family.Metrics = append(family.Metrics, metric)
+	}
+
+	if client != nil {
+		var nodes []disgolink.Node
+		client.ForNodes(func(node disgolink.Node) {
+			nodes = append(nodes, node)
+		})
+		playerCounts := client.PlayerCounts()
+
+		for _, node := range nodes {
+			nodeLabel := Label{Name: "node", Value: node.Config().Name}
+			gauge := func(name string, help string, value float64, labels ...Label) {
+				add(name, help, MetricTypeGauge, Metric{Labels: append([]Label{nodeLabel}, labels...), Value: value})
+			}
+
+			connected := 0.0
+			if node.Status() == disgolink.StatusConnected {
+				connected = 1
+			}
+			gauge("node_connected", "Whether the node is connected.", connected)
+			gauge("node_latency_seconds", "Round trip time of the last websocket ping.", node.Latency().Seconds())
+			gauge("client_players", "Number of players known to the client.", float64(playerCounts[node.Config().Name]))
+
+			stats := node.Stats()
+			gauge("node_players", "Number of players on the node.", float64(stats.Players))
+			gauge("node_playing_players", "Number of playing players on the node.", float64(stats.PlayingPlayers))
+			gauge("node_uptime_seconds", "Uptime of the node.", float64(stats.Uptime.Milliseconds())/1000)
+			gauge("node_memory_bytes", "Memory of the node.", float64(stats.Memory.Free), Label{Name: "type", Value: "free"})
+			gauge("node_memory_bytes", "Memory of the node.", float64(stats.Memory.Used), Label{Name: "type", Value: "used"})
+			gauge("node_memory_bytes", "Memory of the node.", float64(stats.Memory.Allocated), Label{Name: "type", Value: "allocated"})
+			gauge("node_memory_bytes", "Memory of the node.", float64(stats.Memory.Reservable), Label{Name: "type", Value: "reservable"})
+			gauge("node_cpu_cores", "Number of cpu cores of the node.", float64(stats.CPU.Cores))
+			gauge("node_cpu_load", "Cpu load of the node.", stats.CPU.SystemLoad, Label{Name: "type", Value: "system"})
+			gauge("node_cpu_load", "Cpu load of the node.", stats.CPU.LavalinkLoad, Label{Name: "type", Value: "lavalink"})
+			if stats.FrameStats != nil {
+				gauge("node_frames", "Audio frames of the node in the last minute.", float64(stats.FrameStats.Sent), Label{Name: "type", Value: "sent"})
+				gauge("node_frames", "Audio frames of the node in the last minute.", float64(stats.FrameStats.Nulled), Label{Name: "type", Value: "nulled"})
+				gauge("node_frames", "Audio frames of the node in the last minute.", float64(stats.FrameStats.Deficit), Label{Name: "type", Value: "deficit"})
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for node, count := range c.reconnectAttempts {
+		add("node_reconnect_attempts_total", "Number of reconnect attempts.", MetricTypeCounter, Metric{Labels: []Label{{Name: "node", Value: node}}, Value: float64(count)})
+	}
+	for node, count := range c.reconnects {
+		add("node_reconnects_total", "Number of successful reconnects.", MetricTypeCounter, Metric{Labels: []Label{{Name: "node", Value: node}}, Value: float64(count)})
+	}
+	for key, count := range c.events {
+		add("events_total", "Number of emitted events.", MetricTypeCounter, Metric{
+			Labels: []Label{{Name: "op", Value: string(key.op)}, {Name: "type", Value: key.eventType}},
+			Value:  float64(count),
+		})
+	}
+	for key, h := range c.restLatencies {
+		metric := h.metric()
+		metric.Labels = []Label{
+			{Name: "node", Value: key.node},
+			{Name: "method", Value: key.method},
+			{Name: "endpoint", Value: key.endpoint},
+			{Name: "status", Value: key.status},
+		}
+		add("rest_request_duration_seconds", "Latency of rest requests.", MetricTypeHistogram, metric)
+	}
+
+	result := make([]MetricFamily, 0, len(families))
+	for _, family := range families {
+		sort.SliceStable(family.Metrics, func(i, j int) bool {
+			return labelsString(family.Metrics[i].Labels) < labelsString(family.Metrics[j].Labels)
+		})
+		result = append(result, *family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	for _, family := range c.Gather() {
+		_, _ = fmt.Fprintf(&sb, "# HELP %s %s\n", family.Name, family.Help)
+		_, _ = fmt.Fprintf(&sb, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, metric := range family.Metrics {
+			if family.Type != MetricTypeHistogram {
+				_, _ = fmt.Fprintf(&sb, "%s%s %s\n", family.Name, labelsString(metric.Labels), formatFloat(metric.Value))
+				continue
+			}
+			for _, bucket := range metric.Buckets {
+				labels := append(metric.Labels[:len(metric.Labels):len(metric.Labels)], Label{Name: "le", Value: formatFloat(bucket.UpperBound)})
+				_, _ = fmt.Fprintf(&sb, "%s_bucket%s %d\n", family.Name, labelsString(labels), bucket.Count)
+			}
+			labels := append(metric.Labels[:len(metric.Labels):len(metric.Labels)], Label{Name: "le", Value: "+Inf"})
+			_, _ = fmt.Fprintf(&sb, "%s_bucket%s %d\n", family.Name, labelsString(labels), metric.Count)
+			_, _ = fmt.Fprintf(&sb, "%s_sum%s %s\n", family.Name, labelsString(metric.Labels), formatFloat(metric.Sum))
+			_, _ = fmt.Fprintf(&sb, "%s_count%s %d\n", family.Name, labelsString(metric.Labels), metric.Count)
+		}
+	}
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+func labelsString(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(label.Name)
+		sb.WriteString(`="`)
+		sb.WriteString(labelValueReplacer.Replace(label.Value))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		upperBounds: buckets,
+		counts:      make([]uint64, len(buckets)),
+	}
+}
+
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upperBound := range h.upperBounds {
+		if v <= upperBound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) metric() Metric {
+	buckets := make([]Bucket, len(h.upperBounds))
+	for i, upperBound := range h.upperBounds {
+		buckets[i] = Bucket{UpperBound: upperBound, Count: h.counts[i]}
+	}
+	return Metric{
+		Buckets: buckets,
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+}
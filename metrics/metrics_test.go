@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/disgoorg/disgolink/v3/disgolink"
+	"github.com/disgoorg/disgolink/v3/lavalink"
+	"github.com/disgoorg/snowflake/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector(t *testing.T) {
+	collector := New("")
+	client := disgolink.New(snowflake.ID(1), collector.ConfigOpt())
+
+	client.EmitEvent(nil, lavalink.TrackStartEvent{GuildID_: 2})
+	client.EmitEvent(nil, lavalink.TrackStartEvent{GuildID_: 3})
+	client.EmitEvent(nil, lavalink.StatsMessage{})
+	client.EmitEvent(nil, disgolink.NodeErrorEvent{})
+
+	handler := collector.RestMiddleware(func(rq *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound}, nil
+	})
+	rq := httptest.NewRequest(http.MethodGet, "http://localhost:2333/v4/sessions/abc/players/2", nil)
+	_, err := handler(rq)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, nil)
+	body := rec.Body.String()
+
+	assert.Contains(t, body, "# TYPE disgolink_events_total counter\n")
+	assert.Contains(t, body, `disgolink_events_total{op="event",type="TrackStartEvent"} 2`+"\n")
+	assert.Contains(t, body, `disgolink_events_total{op="stats",type=""} 1`+"\n")
+	assert.Contains(t, body, `disgolink_events_total{op="node",type="NodeErrorEvent"} 1`+"\n")
+	assert.Contains(t, body, "# TYPE disgolink_rest_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `disgolink_rest_request_duration_seconds_count{node="localhost:2333",method="GET",endpoint="/v4/sessions/{sessionId}/players/{guildId}",status="404"} 1`+"\n")
+	assert.Contains(t, body, `disgolink_rest_request_duration_seconds_bucket{node="localhost:2333",method="GET",endpoint="/v4/sessions/{sessionId}/players/{guildId}",status="404",le="+Inf"} 1`+"\n")
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			assert.Len(t, strings.Fields(line), 2, line)
+		}
+	}
+}
+
+type testNode struct {
+	disgolink.Node
+	client disgolink.Client
+}
+
+func (n *testNode) Config() disgolink.NodeConfig {
+	return disgolink.NodeConfig{Name: "test", Address: "localhost:2333"}
+}
+
+func (n *testNode) Lavalink() disgolink.Client {
+	return n.client
+}
+
+func TestCollector_RestNodeName(t *testing.T) {
+	collector := New("")
+	client := disgolink.New(snowflake.ID(1), collector.ConfigOpt())
+	node := &testNode{client: client}
+
+	handler := collector.RestMiddleware(func(rq *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+	request := func() {
+		_, err := handler(httptest.NewRequest(http.MethodGet, "http://localhost:2333/version", nil))
+		require.NoError(t, err)
+	}
+
+	client.EmitEvent(nil, disgolink.NodeReadyEvent{Node_: node})
+	request()
+	client.EmitEvent(nil, disgolink.NodeRemovedEvent{Node_: node})
+	request()
+
+	body := func() string {
+		rec := httptest.NewRecorder()
+		collector.ServeHTTP(rec, nil)
+		return rec.Body.String()
+	}()
+	assert.Contains(t, body, `disgolink_rest_request_duration_seconds_count{node="test",method="GET",endpoint="/version",status="200"} 1`+"\n")
+	assert.Contains(t, body, `disgolink_rest_request_duration_seconds_count{node="localhost:2333",method="GET",endpoint="/version",status="200"} 1`+"\n")
+}
+
+func TestLabelsString(t *testing.T) {
+	assert.Equal(t, `{a="x\\y\"z\n"}`, labelsString([]Label{{Name: "a", Value: "x\\y\"z\n"}}))
+}